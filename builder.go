@@ -15,16 +15,31 @@
 package dartsclone
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/ikawaha/dartsclone/internal"
 )
 
 // BuildTRIE returns a dartsclone TRIE for keys and values.
+// The TRIE is arranged in the 64-bit layout if the 32-bit layout cannot hold it.
 func BuildTRIE(keys []string, values []uint32, progress ProgressFunction) (Trie, error) {
-	return internal.BuildDoubleArray(keys, values, progress)
+	b := internal.NewDoubleArrayBuilder(progress)
+	if err := b.Build(keys, values); err != nil {
+		return nil, fmt.Errorf("build error, %v", err)
+	}
+	if b.IsUint64() {
+		return b.DoubleArrayUint64()
+	}
+	return b.DoubleArrayUint32()
 }
 
+// BuildStats represents the statistics of the dartsclone TRIE built by the builder.
+type BuildStats = internal.BuildStats
+
+// KeySource is the stream of the keys in the ascending order and their values, which is read by BuildStream.
+type KeySource = internal.KeySource
+
 // Builder represents builder of the dartsclone TRIE.
 type Builder struct {
 	*internal.DoubleArrayBuilder
@@ -16,6 +16,7 @@ package dartsclone
 
 import (
 	"bufio"
+	"bytes"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -128,3 +129,47 @@ func TestBuildTRIE(t *testing.T) {
 		})
 	})
 }
+
+func TestBuildTRIE_Uint64(t *testing.T) {
+	keys := []string{
+		"電気",
+		"電気通信",
+		"電気通信大学",
+	}
+	values := []uint32{1 << 31, 1<<32 - 1, 0}
+	trie, err := BuildTRIE(keys, values, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	for i, key := range keys {
+		id, size, err := trie.ExactMatchSearch(key)
+		if err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if got, expected := id, int(values[i]); got != expected {
+			t.Errorf("got %v, expected %v", got, expected)
+		}
+		if got, expected := size, len(key); got != expected {
+			t.Errorf("got %v, expected %v", got, expected)
+		}
+	}
+}
+
+func TestBuilder_Stats(t *testing.T) {
+	keys := []string{"電気", "電気通信", "電気通信大学"}
+	b := NewBuilder(nil)
+	if err := b.Build(keys, nil); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	var stats BuildStats = b.Stats()
+	if got, expected := stats.NumKeys, len(keys); got != expected {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+	var buf bytes.Buffer
+	if _, err := b.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if got, expected := stats.Bytes, int64(buf.Len()); got != expected {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+}
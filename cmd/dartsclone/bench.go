@@ -0,0 +1,212 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ikawaha/dartsclone"
+	"github.com/ikawaha/dartsclone/server"
+)
+
+func runBench(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	mode := fs.String("mode", modeExact, "search mode, exact or prefix")
+	mmap := fs.Bool("mmap", false, "map the TRIE file on the memory")
+	goroutines := fs.Int("goroutines", runtime.GOMAXPROCS(0), "number of goroutines to search")
+	repeat := fs.Int("repeat", 1, "number of times to replay the queries")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dartsclone bench [options] <TRIE file> [query file]")
+		fmt.Fprintln(stderr, "Replays the queries, one per line, and reports the throughput, the latency percentiles and the allocations.")
+		fmt.Fprintln(stderr, "The queries are read from the standard input if the query file is omitted.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 || *goroutines < 1 || *repeat < 1 {
+		fs.Usage()
+		return errUsage
+	}
+	var search func(t dartsclone.Trie, query string) (bool, error)
+	switch *mode {
+	case modeExact:
+		search = func(t dartsclone.Trie, query string) (bool, error) {
+			id, _, err := t.ExactMatchSearch(query)
+			return id >= 0, err
+		}
+	case modePrefix:
+		search = func(t dartsclone.Trie, query string) (bool, error) {
+			ret, err := t.CommonPrefixSearch(query, 0)
+			return len(ret) > 0, err
+		}
+	default:
+		return fmt.Errorf("unknown search mode %q", *mode)
+	}
+	r := stdin
+	if fs.NArg() == 2 {
+		f, err := os.Open(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	queries, err := readQueries(r)
+	if err != nil {
+		return err
+	}
+	if len(queries) == 0 {
+		return fmt.Errorf("no queries")
+	}
+
+	start := time.Now()
+	t, c, err := server.OpenTrie(fs.Arg(0), *mmap)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	loadTime := time.Since(start)
+
+	ret, err := bench(t, queries, search, *goroutines, *repeat)
+	if err != nil {
+		return err
+	}
+	loading := "heap"
+	if *mmap {
+		loading = "mmap"
+	}
+	fmt.Fprintf(stdout, "loading\t%s\n", loading)
+	fmt.Fprintf(stdout, "load time\t%v\n", loadTime)
+	fmt.Fprintf(stdout, "mode\t%s\n", *mode)
+	fmt.Fprintf(stdout, "goroutines\t%d\n", *goroutines)
+	ret.print(stdout)
+	return nil
+}
+
+func readQueries(r io.Reader) ([]string, error) {
+	var ret []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		ret = append(ret, scanner.Text())
+	}
+	return ret, scanner.Err()
+}
+
+type benchResult struct {
+	queries    int
+	hits       int
+	elapsed    time.Duration
+	latencies  []time.Duration // sorted
+	mallocs    uint64
+	allocBytes uint64
+}
+
+// bench replays the queries repeat times across the goroutines.
+// The goroutine i searches the queries i, i+goroutines, i+2*goroutines, ...
+func bench(t dartsclone.Trie, queries []string, search func(t dartsclone.Trie, query string) (bool, error), goroutines, repeat int) (benchResult, error) {
+	type result struct {
+		hits      int
+		latencies []time.Duration
+		err       error
+	}
+	results := make([]result, goroutines)
+	for i := range results {
+		// allocates the latencies in advance not to count them in the allocations of the searches.
+		results[i].latencies = make([]time.Duration, 0, repeat*(len(queries)/goroutines+1))
+	}
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := &results[i]
+			for n := 0; n < repeat; n++ {
+				for j := i; j < len(queries); j += goroutines {
+					s := time.Now()
+					hit, err := search(t, queries[j])
+					r.latencies = append(r.latencies, time.Since(s))
+					if err != nil {
+						r.err = fmt.Errorf("search %q, %v", queries[j], err)
+						return
+					}
+					if hit {
+						r.hits++
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	ret := benchResult{
+		elapsed:    elapsed,
+		mallocs:    after.Mallocs - before.Mallocs,
+		allocBytes: after.TotalAlloc - before.TotalAlloc,
+	}
+	for _, r := range results {
+		if r.err != nil {
+			return ret, r.err
+		}
+		ret.hits += r.hits
+		ret.latencies = append(ret.latencies, r.latencies...)
+	}
+	ret.queries = len(ret.latencies)
+	sort.Slice(ret.latencies, func(i, j int) bool { return ret.latencies[i] < ret.latencies[j] })
+	return ret, nil
+}
+
+// percentile returns the latency of the p-th percentile, 0 < p <= 100.
+func (r benchResult) percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	i := int(float64(len(r.latencies))*p/100+0.5) - 1
+	if i < 0 {
+		i = 0
+	} else if i >= len(r.latencies) {
+		i = len(r.latencies) - 1
+	}
+	return r.latencies[i]
+}
+
+func (r benchResult) print(w io.Writer) {
+	fmt.Fprintf(w, "queries\t%d\n", r.queries)
+	fmt.Fprintf(w, "hits\t%d\n", r.hits)
+	fmt.Fprintf(w, "elapsed\t%v\n", r.elapsed)
+	fmt.Fprintf(w, "throughput\t%.0f queries/s\n", float64(r.queries)/r.elapsed.Seconds())
+	for _, p := range []float64{50, 90, 99, 99.9} {
+		fmt.Fprintf(w, "p%v\t%v\n", p, r.percentile(p))
+	}
+	fmt.Fprintf(w, "max\t%v\n", r.percentile(100))
+	fmt.Fprintf(w, "allocs/query\t%.2f\n", float64(r.mallocs)/float64(r.queries))
+	fmt.Fprintf(w, "bytes/query\t%.2f\n", float64(r.allocBytes)/float64(r.queries))
+}
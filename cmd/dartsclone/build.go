@@ -0,0 +1,142 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ikawaha/dartsclone"
+	"github.com/ikawaha/dartsclone/progressbar"
+)
+
+func runBuild(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("build", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("o", "", "output TRIE file (required)")
+	parallelism := fs.Int("parallel", 1, "number of goroutines to build, 0 means GOMAXPROCS")
+	progress := fs.Bool("progress", false, "show the progress bar")
+	stats := fs.Bool("stats", false, "print the build statistics")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dartsclone build -o <file> [options] [key file]")
+		fmt.Fprintln(stderr, "Each line of the key file is a key or a key and a value separated by a tab.")
+		fmt.Fprintln(stderr, "The keys are read from the standard input if the key file is omitted.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if *output == "" || fs.NArg() > 1 {
+		fs.Usage()
+		return errUsage
+	}
+	r := stdin
+	if fs.NArg() == 1 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	keys, values, err := readKeys(r)
+	if err != nil {
+		return err
+	}
+
+	var p dartsclone.ProgressFunction
+	if *progress {
+		p = progressbar.New()
+	}
+	b := dartsclone.NewBuilder(p)
+	if *parallelism == 1 {
+		err = b.Build(keys, values)
+	} else {
+		err = b.BuildParallel(keys, values, *parallelism)
+	}
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if _, err := b.WriteTo(f); err != nil {
+		f.Close()
+		return fmt.Errorf("write %v, %v", *output, err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if *stats {
+		printBuildStats(stdout, b.Stats())
+	}
+	return nil
+}
+
+// readKeys reads the keys and the values from the lines of the reader.
+// The values are nil if no line has a value.
+func readKeys(r io.Reader) ([]string, []uint32, error) {
+	var keys []string
+	var values []uint32
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" {
+			continue
+		}
+		key, value, hasValue := text, "", false
+		if i := strings.LastIndexByte(text, '\t'); i >= 0 {
+			key, value, hasValue = text[:i], text[i+1:], true
+		}
+		if len(keys) > 0 && hasValue != (values != nil) {
+			return nil, nil, fmt.Errorf("line %d: all lines must have values or no lines have values", line)
+		}
+		keys = append(keys, key)
+		if !hasValue {
+			continue
+		}
+		v, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: invalid value, %v", line, err)
+		}
+		values = append(values, uint32(v))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return keys, values, nil
+}
+
+func printBuildStats(w io.Writer, s dartsclone.BuildStats) {
+	fmt.Fprintf(w, "keys\t%d\n", s.NumKeys)
+	fmt.Fprintf(w, "key bytes\t%d\n", s.KeyBytes)
+	fmt.Fprintf(w, "DAWG nodes\t%d\n", s.NumDAWGNodes)
+	fmt.Fprintf(w, "intersections\t%d\n", s.NumIntersections)
+	fmt.Fprintf(w, "units\t%d\n", s.NumUnits)
+	fmt.Fprintf(w, "filler units\t%d\n", s.NumFillerUnits)
+	fmt.Fprintf(w, "bytes\t%d\n", s.Bytes)
+	fmt.Fprintf(w, "compression ratio\t%.4f\n", s.CompressionRatio)
+	fmt.Fprintf(w, "sort time\t%v\n", s.SortTime)
+	fmt.Fprintf(w, "DAWG time\t%v\n", s.DAWGTime)
+	fmt.Fprintf(w, "arrange time\t%v\n", s.ArrangeTime)
+	fmt.Fprintf(w, "fix blocks time\t%v\n", s.FixBlocksTime)
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/ikawaha/dartsclone"
+	"github.com/ikawaha/dartsclone/server"
+)
+
+func runDiff(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	mmap := fs.Bool("mmap", false, "map the TRIE files on the memory")
+	stat := fs.Bool("stat", false, "print only the numbers of the added, removed and changed keys")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dartsclone diff [options] <old TRIE file> <new TRIE file>")
+		fmt.Fprintln(stderr, "Prints the added keys as '+ key id', the removed keys as '- key id'")
+		fmt.Fprintln(stderr, "and the keys with changed ids as '~ key old-id new-id', separated by tabs.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errUsage
+	}
+	oldTrie, oldCloser, err := server.OpenTrie(fs.Arg(0), *mmap)
+	if err != nil {
+		return err
+	}
+	defer oldCloser.Close()
+	newTrie, newCloser, err := server.OpenTrie(fs.Arg(1), *mmap)
+	if err != nil {
+		return err
+	}
+	defer newCloser.Close()
+
+	w := bufio.NewWriter(stdout)
+	var added, removed, changed int
+	err = diff(oldTrie, newTrie, func(op byte, key string, oldID, newID int) {
+		switch op {
+		case '+':
+			added++
+			if !*stat {
+				fmt.Fprintf(w, "+\t%s\t%d\n", key, newID)
+			}
+		case '-':
+			removed++
+			if !*stat {
+				fmt.Fprintf(w, "-\t%s\t%d\n", key, oldID)
+			}
+		case '~':
+			changed++
+			if !*stat {
+				fmt.Fprintf(w, "~\t%s\t%d\t%d\n", key, oldID, newID)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if *stat {
+		fmt.Fprintf(w, "added\t%d\nremoved\t%d\nchanged\t%d\n", added, removed, changed)
+	}
+	return w.Flush()
+}
+
+// diff merges the keys of the TRIEs in the order of the keys and calls the callback with the differences.
+// The op is '+' for the keys only in the new TRIE, '-' for the keys only in the old TRIE
+// and '~' for the keys with the different ids.
+func diff(oldTrie, newTrie dartsclone.Trie, callback func(op byte, key string, oldID, newID int)) error {
+	oldIter, newIter := dartsclone.Keys(oldTrie, ""), dartsclone.Keys(newTrie, "")
+	hasOld, hasNew := oldIter.Next(), newIter.Next()
+	for hasOld || hasNew {
+		switch {
+		case !hasNew || hasOld && oldIter.Key() < newIter.Key():
+			callback('-', oldIter.Key(), oldIter.ID(), -1)
+			hasOld = oldIter.Next()
+		case !hasOld || newIter.Key() < oldIter.Key():
+			callback('+', newIter.Key(), -1, newIter.ID())
+			hasNew = newIter.Next()
+		default:
+			if oldIter.ID() != newIter.ID() {
+				callback('~', oldIter.Key(), oldIter.ID(), newIter.ID())
+			}
+			hasOld, hasNew = oldIter.Next(), newIter.Next()
+		}
+	}
+	if err := oldIter.Err(); err != nil {
+		return fmt.Errorf("old TRIE, %v", err)
+	}
+	if err := newIter.Err(); err != nil {
+		return fmt.Errorf("new TRIE, %v", err)
+	}
+	return nil
+}
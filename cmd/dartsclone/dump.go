@@ -0,0 +1,98 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/ikawaha/dartsclone"
+	"github.com/ikawaha/dartsclone/dawg"
+	"github.com/ikawaha/dartsclone/server"
+)
+
+func runDump(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("dump", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	prefix := fs.String("prefix", "", "print only the keys which start with the prefix")
+	mmap := fs.Bool("mmap", false, "map the TRIE file on the memory")
+	dot := fs.Bool("dot", false, "print the units reachable from the prefix in the Graphviz DOT language instead of the keys")
+	units := fs.Bool("units", false, "print all units decoded and the anomalies instead of the keys")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dartsclone dump [options] <TRIE file or DAWG file>")
+		fmt.Fprintln(stderr, "Prints the keys and the ids separated by a tab in the order of the keys.")
+		fmt.Fprintln(stderr, "The DOT is rendered by e.g. 'dartsclone dump -dot -prefix key file | dot -Tsvg > file.svg'.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 || *dot && *units {
+		fs.Usage()
+		return errUsage
+	}
+	var predictiveSearch func(prefix string, callback func(key string, id int)) error
+	var writeDOT func(w io.Writer, prefix string) error
+	var dumpUnits func(w io.Writer) error
+	if ok, err := dawg.IsGraphFile(fs.Arg(0)); err != nil {
+		return err
+	} else if ok {
+		g, err := dawg.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		predictiveSearch, writeDOT = g.PredictiveSearchCallback, g.WriteDOT
+		dumpUnits = func(io.Writer) error {
+			return fmt.Errorf("dump of the units is not supported by the DAWG file")
+		}
+	} else {
+		trie, c, err := server.OpenTrie(fs.Arg(0), *mmap)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		predictiveSearch = func(prefix string, callback func(key string, id int)) error {
+			return dartsclone.PredictiveSearchCallback(trie, prefix, callback)
+		}
+		writeDOT = func(w io.Writer, prefix string) error {
+			return dartsclone.WriteDOT(w, trie, prefix)
+		}
+		dumpUnits = func(w io.Writer) error {
+			return dartsclone.DumpUnits(w, trie)
+		}
+	}
+	if *dot {
+		return writeDOT(stdout, *prefix)
+	}
+	if *units {
+		return dumpUnits(stdout)
+	}
+
+	w := bufio.NewWriter(stdout)
+	var werr error
+	if err := predictiveSearch(*prefix, func(key string, id int) {
+		if werr == nil {
+			_, werr = fmt.Fprintf(w, "%s\t%d\n", key, id)
+		}
+	}); err != nil {
+		return err
+	}
+	if werr != nil {
+		return werr
+	}
+	return w.Flush()
+}
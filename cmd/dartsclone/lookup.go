@@ -0,0 +1,121 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/ikawaha/dartsclone"
+	"github.com/ikawaha/dartsclone/server"
+)
+
+const (
+	modeExact      = "exact"
+	modePrefix     = "prefix"
+	modePredictive = "predictive"
+)
+
+func runLookup(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("lookup", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	mode := fs.String("mode", modeExact, "search mode, exact, prefix or predictive")
+	mmap := fs.Bool("mmap", false, "map the TRIE file on the memory")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dartsclone lookup [options] <TRIE file> [key ...]")
+		fmt.Fprintln(stderr, "The keys are read from the standard input if no key is given.")
+		fmt.Fprintln(stderr, "exact prints the key and the id, -1 if not found.")
+		fmt.Fprintln(stderr, "prefix prints the key, the prefix of the key found and the id.")
+		fmt.Fprintln(stderr, "predictive prints the key, the key found which starts with the key and the id.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return errUsage
+	}
+	var search func(w io.Writer, t dartsclone.Trie, key string) error
+	switch *mode {
+	case modeExact:
+		search = exactMatchSearch
+	case modePrefix:
+		search = commonPrefixSearch
+	case modePredictive:
+		search = predictiveSearch
+	default:
+		return fmt.Errorf("unknown search mode %q", *mode)
+	}
+	t, c, err := server.OpenTrie(fs.Arg(0), *mmap)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	w := bufio.NewWriter(stdout)
+	defer w.Flush()
+	if fs.NArg() > 1 {
+		for _, key := range fs.Args()[1:] {
+			if err := search(w, t, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	scanner := bufio.NewScanner(stdin)
+	for scanner.Scan() {
+		if err := search(w, t, scanner.Text()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func exactMatchSearch(w io.Writer, t dartsclone.Trie, key string) error {
+	id, _, err := t.ExactMatchSearch(key)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\t%d\n", key, id)
+	return err
+}
+
+func commonPrefixSearch(w io.Writer, t dartsclone.Trie, key string) error {
+	ret, err := t.CommonPrefixSearch(key, 0)
+	if err != nil {
+		return err
+	}
+	for _, v := range ret {
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%d\n", key, key[:v[1]], v[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func predictiveSearch(w io.Writer, t dartsclone.Trie, key string) error {
+	var werr error
+	if err := dartsclone.PredictiveSearchCallback(t, key, func(k string, id int) {
+		if werr == nil {
+			_, werr = fmt.Fprintf(w, "%s\t%s\t%d\n", key, k, id)
+		}
+	}); err != nil {
+		return err
+	}
+	return werr
+}
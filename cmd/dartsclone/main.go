@@ -0,0 +1,96 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command dartsclone builds, searches and inspects the dartsclone TRIE files.
+//
+// Usage:
+//
+//	dartsclone <command> [arguments]
+//
+// The commands are:
+//
+//	build   build a TRIE file from a key file
+//	lookup  search a TRIE file by keys
+//	dump    print all keys of a TRIE file
+//	stats   print statistics of a TRIE file
+//	verify  check the integrity of a TRIE file
+//	diff    print the differences of the keys of two TRIE files
+//	merge   merge TRIE files into one
+//	bench   measure the search performance of a TRIE file
+//	serve   serve the searches of a TRIE file over HTTP
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+type command struct {
+	name        string
+	description string
+	run         func(args []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+var commands = []command{
+	{name: "build", description: "build a TRIE file from a key file", run: runBuild},
+	{name: "lookup", description: "search a TRIE file by keys", run: runLookup},
+	{name: "dump", description: "print all keys of a TRIE file", run: runDump},
+	{name: "stats", description: "print statistics of a TRIE file", run: runStats},
+	{name: "verify", description: "check the integrity of a TRIE file", run: runVerify},
+	{name: "diff", description: "print the differences of the keys of two TRIE files", run: runDiff},
+	{name: "merge", description: "merge TRIE files into one", run: runMerge},
+	{name: "bench", description: "measure the search performance of a TRIE file", run: runBench},
+	{name: "serve", description: "serve the searches of a TRIE file over HTTP", run: runServe},
+}
+
+// errUsage indicates that the usage has been printed.
+var errUsage = errors.New("usage")
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: dartsclone <command> [arguments]")
+	fmt.Fprintln(w, "The commands are:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s%s\n", c.name, c.description)
+	}
+	fmt.Fprintln(w, `Run "dartsclone <command> -h" for the usage of the command.`)
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) < 1 {
+		usage(stderr)
+		return 2
+	}
+	for _, c := range commands {
+		if c.name != args[0] {
+			continue
+		}
+		if err := c.run(args[1:], stdin, stdout, stderr); err != nil {
+			if err == errUsage {
+				return 2
+			}
+			fmt.Fprintf(stderr, "%s: %v\n", c.name, err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
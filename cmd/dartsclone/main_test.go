@@ -0,0 +1,406 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ikawaha/dartsclone/dawg"
+)
+
+func testBuild(t *testing.T, input string) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "dartsclone")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	name := filepath.Join(dir, "trie.dic")
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"build", "-o", name}, strings.NewReader(input), &stdout, &stderr); code != 0 {
+		os.RemoveAll(dir)
+		t.Fatalf("unexpected exit code %v, %v", code, stderr.String())
+	}
+	return name, func() { os.RemoveAll(dir) }
+}
+
+func TestRun(t *testing.T) {
+	t.Run("no command", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if got, expected := run(nil, nil, &stdout, &stderr), 2; got != expected {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+	t.Run("unknown command", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if got, expected := run([]string{"unknown"}, nil, &stdout, &stderr), 2; got != expected {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+}
+
+func TestBuild(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		ok    bool
+	}{
+		{name: "keys", input: "b\na\n\nc\n", ok: true},
+		{name: "keys and values", input: "b\t2\na\t1\n", ok: true},
+		{name: "missing value", input: "b\t2\na\n"},
+		{name: "invalid value", input: "a\t-1\n"},
+		{name: "duplicate keys", input: "a\na\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "dartsclone")
+			if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			defer os.RemoveAll(dir)
+			var stdout, stderr bytes.Buffer
+			code := run([]string{"build", "-o", filepath.Join(dir, "trie.dic")}, strings.NewReader(tc.input), &stdout, &stderr)
+			if got := code == 0; got != tc.ok {
+				t.Errorf("expected ok=%v, got exit code %v, %v", tc.ok, code, stderr.String())
+			}
+		})
+	}
+	t.Run("without output", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if got, expected := run([]string{"build"}, strings.NewReader("a\n"), &stdout, &stderr), 2; got != expected {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+}
+
+func TestLookup(t *testing.T) {
+	name, cleanup := testBuild(t, "電気\t1\n電気通信\t2\n電気通信大学\t3\nhello\t4\n")
+	defer cleanup()
+	testCases := []struct {
+		args     []string
+		stdin    string
+		expected string
+	}{
+		{
+			args:     []string{"lookup", name, "電気通信", "電"},
+			expected: "電気通信\t2\n電\t-1\n",
+		},
+		{
+			args:     []string{"lookup", name},
+			stdin:    "hello\n電気\n",
+			expected: "hello\t4\n電気\t1\n",
+		},
+		{
+			args:     []string{"lookup", "-mode", "prefix", name, "電気通信大学院"},
+			expected: "電気通信大学院\t電気\t1\n電気通信大学院\t電気通信\t2\n電気通信大学院\t電気通信大学\t3\n",
+		},
+		{
+			args:     []string{"lookup", "-mode", "predictive", name, "電気通"},
+			expected: "電気通\t電気通信\t2\n電気通\t電気通信大学\t3\n",
+		},
+	}
+	for _, tc := range testCases {
+		var stdout, stderr bytes.Buffer
+		if code := run(tc.args, strings.NewReader(tc.stdin), &stdout, &stderr); code != 0 {
+			t.Errorf("unexpected exit code %v, %v", code, stderr.String())
+		}
+		if got := stdout.String(); got != tc.expected {
+			t.Errorf("expected %q, got %q (%v)", tc.expected, got, tc.args)
+		}
+	}
+	t.Run("unknown mode", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if got, expected := run([]string{"lookup", "-mode", "unknown", name, "a"}, nil, &stdout, &stderr), 1; got != expected {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+}
+
+func TestDump(t *testing.T) {
+	input := "hello\t4\n電気\t1\n電気通信\t2\n電気通信大学\t3\n"
+	name, cleanup := testBuild(t, input)
+	defer cleanup()
+	t.Run("all keys", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"dump", name}, nil, &stdout, &stderr); code != 0 {
+			t.Errorf("unexpected exit code %v, %v", code, stderr.String())
+		}
+		if got := stdout.String(); got != input {
+			t.Errorf("expected %q, got %q", input, got)
+		}
+	})
+	t.Run("prefix", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"dump", "-prefix", "h", name}, nil, &stdout, &stderr); code != 0 {
+			t.Errorf("unexpected exit code %v, %v", code, stderr.String())
+		}
+		if got, expected := stdout.String(), "hello\t4\n"; got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	})
+	t.Run("dot", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"dump", "-dot", "-prefix", "h", name}, nil, &stdout, &stderr); code != 0 {
+			t.Errorf("unexpected exit code %v, %v", code, stderr.String())
+		}
+		got := stdout.String()
+		if !strings.HasPrefix(got, "digraph doublearray {") || !strings.Contains(got, "id=4") || strings.Contains(got, "id=1") {
+			t.Errorf("unexpected graph, %v", got)
+		}
+	})
+	t.Run("units", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"dump", "-units", name}, nil, &stdout, &stderr); code != 0 {
+			t.Errorf("unexpected exit code %v, %v", code, stderr.String())
+		}
+		got := stdout.String()
+		if !strings.Contains(got, "\tleaf\tvalue=4 key=\"hello\"\n") || !strings.Contains(got, "leaves 4, fillers") {
+			t.Errorf("unexpected units, %v", got)
+		}
+		if code := run([]string{"dump", "-units", "-dot", name}, nil, &stdout, &stderr); code != 2 {
+			t.Errorf("expected exit code 2, got %v", code)
+		}
+	})
+	t.Run("DAWG", func(t *testing.T) {
+		b := dawg.NewBuilder()
+		for i, key := range []string{"a", "ab", "b"} {
+			if err := b.Insert(key, uint32(i)); err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+		}
+		g, err := b.Finish()
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		name := filepath.Join(filepath.Dir(name), "dawg")
+		f, err := os.Create(name)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		g.WriteTo(f)
+		f.Close()
+
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"dump", name}, nil, &stdout, &stderr); code != 0 {
+			t.Errorf("unexpected exit code %v, %v", code, stderr.String())
+		}
+		if got, expected := stdout.String(), "a\t0\nab\t1\nb\t2\n"; got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+		stdout.Reset()
+		if code := run([]string{"dump", "-dot", name}, nil, &stdout, &stderr); code != 0 {
+			t.Errorf("unexpected exit code %v, %v", code, stderr.String())
+		}
+		if got := stdout.String(); !strings.HasPrefix(got, "digraph dawg {") {
+			t.Errorf("unexpected graph, %v", got)
+		}
+	})
+}
+
+func TestStats(t *testing.T) {
+	name, cleanup := testBuild(t, "a\nab\nabc\n")
+	defer cleanup()
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"stats", name}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("unexpected exit code %v, %v", code, stderr.String())
+	}
+	for _, expected := range []string{"layout\t32-bit\n", "keys\t3\n", "key bytes\t6\n"} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("expected %q in %q", expected, stdout.String())
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	name, cleanup := testBuild(t, "a\t1\nab\t2\nabc\t3\nb\t4\n")
+	defer cleanup()
+	t.Run("ok", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"verify", name}, nil, &stdout, &stderr); code != 0 {
+			t.Errorf("unexpected exit code %v, %v", code, stderr.String())
+		}
+		if !strings.Contains(stdout.String(), "ok, 4 keys") {
+			t.Errorf("unexpected output %q", stdout.String())
+		}
+	})
+	t.Run("broken", func(t *testing.T) {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		broken := name + ".broken"
+		if err := ioutil.WriteFile(broken, b[:len(b)-4], 0644); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		var stdout, stderr bytes.Buffer
+		if got, expected := run([]string{"verify", broken}, nil, &stdout, &stderr), 1; got != expected {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+	t.Run("broken structure", func(t *testing.T) {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		// clears the first leaf unit, which has the value flag.
+		for i := 0; i < len(b); i += 4 {
+			if b[i+3]&0x80 != 0 {
+				copy(b[i:i+4], []byte{0, 0, 0, 0})
+				break
+			}
+		}
+		broken := name + ".broken"
+		if err := ioutil.WriteFile(broken, b, 0644); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		var stdout, stderr bytes.Buffer
+		if got, expected := run([]string{"verify", broken}, nil, &stdout, &stderr), 1; got != expected {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+		if !strings.Contains(stdout.String(), "invalid leaf") {
+			t.Errorf("unexpected output %q", stdout.String())
+		}
+	})
+}
+
+func TestDiff(t *testing.T) {
+	oldName, cleanupOld := testBuild(t, "a\t1\nab\t2\nb\t3\nc\t4\n")
+	defer cleanupOld()
+	newName, cleanupNew := testBuild(t, "a\t1\nab\t5\nabc\t6\nc\t4\nd\t7\n")
+	defer cleanupNew()
+	t.Run("keys", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"diff", oldName, newName}, nil, &stdout, &stderr); code != 0 {
+			t.Errorf("unexpected exit code %v, %v", code, stderr.String())
+		}
+		expected := "~\tab\t2\t5\n+\tabc\t6\n-\tb\t3\n+\td\t7\n"
+		if got := stdout.String(); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	})
+	t.Run("stat", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"diff", "-stat", oldName, newName}, nil, &stdout, &stderr); code != 0 {
+			t.Errorf("unexpected exit code %v, %v", code, stderr.String())
+		}
+		if got, expected := stdout.String(), "added\t2\nremoved\t1\nchanged\t1\n"; got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	})
+	t.Run("same file", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"diff", oldName, oldName}, nil, &stdout, &stderr); code != 0 {
+			t.Errorf("unexpected exit code %v, %v", code, stderr.String())
+		}
+		if got := stdout.String(); got != "" {
+			t.Errorf("expected no differences, got %q", got)
+		}
+	})
+}
+
+func TestMerge(t *testing.T) {
+	first, cleanupFirst := testBuild(t, "a\t1\nab\t2\n")
+	defer cleanupFirst()
+	second, cleanupSecond := testBuild(t, "ab\t3\nb\t4\n")
+	defer cleanupSecond()
+	testCases := []struct {
+		name     string
+		args     []string
+		code     int
+		expected string
+	}{
+		{name: "keep first", args: nil, expected: "a\t1\nab\t2\nb\t4\n"},
+		{name: "keep last", args: []string{"-conflict", "last"}, expected: "a\t1\nab\t3\nb\t4\n"},
+		{name: "offsets", args: []string{"-conflict", "last", "-offsets", "0,10"}, expected: "a\t1\nab\t13\nb\t14\n"},
+		{name: "reject conflict", args: []string{"-conflict", "error"}, code: 1},
+		{name: "unknown conflict", args: []string{"-conflict", "unknown"}, code: 1},
+		{name: "offsets mismatch", args: []string{"-offsets", "1"}, code: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output := filepath.Join(filepath.Dir(first), "merged.dic")
+			defer os.Remove(output)
+			args := append([]string{"merge", "-o", output}, tc.args...)
+			args = append(args, first, second)
+			var stdout, stderr bytes.Buffer
+			if code := run(args, nil, &stdout, &stderr); code != tc.code {
+				t.Fatalf("expected exit code %v, got %v, %v", tc.code, code, stderr.String())
+			}
+			if tc.code != 0 {
+				return
+			}
+			if code := run([]string{"dump", output}, nil, &stdout, &stderr); code != 0 {
+				t.Fatalf("unexpected exit code %v, %v", code, stderr.String())
+			}
+			if got := stdout.String(); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+	t.Run("no output", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		if code := run([]string{"merge", first}, nil, &stdout, &stderr); code != 2 {
+			t.Errorf("expected exit code 2, got %v", code)
+		}
+	})
+}
+
+func TestBench(t *testing.T) {
+	name, cleanup := testBuild(t, "a\nab\nabc\nb\n")
+	defer cleanup()
+	testCases := []struct {
+		args []string
+		hits string
+	}{
+		{args: []string{"bench", "-goroutines", "2", "-repeat", "3", name}, hits: "hits\t9\n"},
+		{args: []string{"bench", "-mode", "prefix", name}, hits: "hits\t4\n"},
+	}
+	for _, tc := range testCases {
+		var stdout, stderr bytes.Buffer
+		if code := run(tc.args, strings.NewReader("a\nabc\nx\nb\nabcd\n"), &stdout, &stderr); code != 0 {
+			t.Errorf("unexpected exit code %v, %v", code, stderr.String())
+		}
+		for _, expected := range []string{tc.hits, "p99\t", "allocs/query\t"} {
+			if !strings.Contains(stdout.String(), expected) {
+				t.Errorf("expected %q in %q (%v)", expected, stdout.String(), tc.args)
+			}
+		}
+	}
+}
+
+func TestBenchResult_Percentile(t *testing.T) {
+	r := benchResult{}
+	for i := 1; i <= 100; i++ {
+		r.latencies = append(r.latencies, time.Duration(i))
+	}
+	for _, tc := range []struct {
+		p        float64
+		expected time.Duration
+	}{
+		{p: 50, expected: 50},
+		{p: 99, expected: 99},
+		{p: 99.9, expected: 100},
+		{p: 100, expected: 100},
+	} {
+		if got := r.percentile(tc.p); got != tc.expected {
+			t.Errorf("p%v: expected %v, got %v", tc.p, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ikawaha/dartsclone"
+	"github.com/ikawaha/dartsclone/progressbar"
+	"github.com/ikawaha/dartsclone/server"
+)
+
+func runMerge(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("o", "", "output TRIE file (required)")
+	conflict := fs.String("conflict", "first", "resolution of the keys in the multiple TRIE files, first, last or error")
+	offsets := fs.String("offsets", "", "comma separated offsets added to the ids of each TRIE file")
+	mmap := fs.Bool("mmap", false, "map the TRIE files on the memory")
+	progress := fs.Bool("progress", false, "show the progress bar")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dartsclone merge -o <file> [options] <TRIE file>...")
+		fmt.Fprintln(stderr, "Merges the keys of the TRIE files into one TRIE file.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if *output == "" || fs.NArg() < 1 {
+		fs.Usage()
+		return errUsage
+	}
+	var resolve dartsclone.ConflictFunc
+	switch *conflict {
+	case "first":
+		resolve = dartsclone.KeepFirst
+	case "last":
+		resolve = dartsclone.KeepLast
+	case "error":
+		resolve = dartsclone.RejectConflict
+	default:
+		return fmt.Errorf("unknown conflict resolution %q", *conflict)
+	}
+	deltas, err := parseOffsets(*offsets, fs.NArg())
+	if err != nil {
+		return err
+	}
+
+	sources := make([]dartsclone.MergeSource, fs.NArg())
+	for i, name := range fs.Args() {
+		t, c, err := server.OpenTrie(name, *mmap)
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+		sources[i].Trie = t
+		if delta := deltas[i]; delta != 0 {
+			sources[i].Remap = func(key string, id int) uint32 {
+				return uint32(id) + delta
+			}
+		}
+	}
+	var p dartsclone.ProgressFunction
+	if *progress {
+		p = progressbar.New()
+	}
+	b, err := dartsclone.Merge(sources, resolve, p)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if _, err := b.WriteTo(f); err != nil {
+		f.Close()
+		return fmt.Errorf("write %v, %v", *output, err)
+	}
+	return f.Close()
+}
+
+// parseOffsets parses the comma separated offsets of the ids of n TRIE files.
+// All offsets are 0 if s is empty.
+func parseOffsets(s string, n int) ([]uint32, error) {
+	ret := make([]uint32, n)
+	if s == "" {
+		return ret, nil
+	}
+	fields := strings.Split(s, ",")
+	if len(fields) != n {
+		return nil, fmt.Errorf("%d offsets for %d TRIE files", len(fields), n)
+	}
+	for i, v := range fields {
+		offset, err := strconv.ParseUint(strings.TrimSpace(v), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset, %v", err)
+		}
+		ret[i] = uint32(offset)
+	}
+	return ret, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ikawaha/dartsclone/server"
+)
+
+func runServe(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("addr", ":8080", "address to listen")
+	mmap := fs.Bool("mmap", false, "map the TRIE file on the memory")
+	watch := fs.Duration("watch", 0, "interval to check the modification of the TRIE file, 0 disables the check")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dartsclone serve [options] <TRIE file>")
+		fmt.Fprintln(stderr, "Serves the searches over HTTP/JSON. SIGHUP reloads the TRIE file.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	logger := log.New(stderr, "", log.LstdFlags)
+	s, err := server.New(fs.Arg(0), *mmap)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	s.ErrorLog = logger
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if *watch > 0 {
+		go s.Watch(ctx, *watch)
+	}
+	hup := make(chan os.Signal, 1)
+	if len(reloadSignals) > 0 {
+		signal.Notify(hup, reloadSignals...)
+		defer signal.Stop(hup)
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				if err := s.Reload(); err != nil {
+					logger.Printf("reload %v, %v", fs.Arg(0), err)
+					continue
+				}
+				logger.Printf("reloaded %v", fs.Arg(0))
+			}
+		}
+	}()
+
+	srv := &http.Server{Addr: *addr, Handler: s, ErrorLog: logger}
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-quit:
+			shutdown, done := context.WithTimeout(context.Background(), 10*time.Second)
+			defer done()
+			srv.Shutdown(shutdown)
+		}
+	}()
+	logger.Printf("serving %v on %v", fs.Arg(0), *addr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
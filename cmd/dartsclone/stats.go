@@ -0,0 +1,71 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ikawaha/dartsclone"
+	"github.com/ikawaha/dartsclone/server"
+)
+
+func runStats(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	mmap := fs.Bool("mmap", false, "map the TRIE file on the memory")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dartsclone stats [options] <TRIE file>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	name := fs.Arg(0)
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	layout, err := dartsclone.FileLayout(name)
+	if err != nil {
+		return err
+	}
+	t, c, err := server.OpenTrie(name, *mmap)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	var keys, keyBytes int64
+	if err := dartsclone.PredictiveSearchCallback(t, "", func(key string, id int) {
+		keys++
+		keyBytes += int64(len(key))
+	}); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "file\t%s\n", name)
+	fmt.Fprintf(stdout, "layout\t%s\n", layout)
+	fmt.Fprintf(stdout, "bytes\t%d\n", info.Size())
+	fmt.Fprintf(stdout, "units\t%d\n", layout.NumUnits(info.Size()))
+	fmt.Fprintf(stdout, "keys\t%d\n", keys)
+	fmt.Fprintf(stdout, "key bytes\t%d\n", keyBytes)
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ikawaha/dartsclone"
+	"github.com/ikawaha/dartsclone/server"
+)
+
+func runVerify(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	mmap := fs.Bool("mmap", false, "map the TRIE file on the memory")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: dartsclone verify [options] <TRIE file>")
+		fmt.Fprintln(stderr, "Checks the size of the file, the structure of the units reachable from the root")
+		fmt.Fprintln(stderr, "and that every key of the TRIE is found by the searches.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+	name := fs.Arg(0)
+	if err := verifySize(name); err != nil {
+		return fmt.Errorf("%v: %v", name, err)
+	}
+	t, c, err := server.OpenTrie(name, *mmap)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if err := dartsclone.Validate(t); err != nil {
+		if v, ok := err.(*dartsclone.ValidationError); ok {
+			for _, p := range v.Problems {
+				fmt.Fprintf(stdout, "%s: unit %d: %s\n", name, p.Index, p.Message)
+			}
+		}
+		return fmt.Errorf("%v: %v", name, err)
+	}
+
+	var n int
+	var prev string
+	it := dartsclone.Keys(t, "")
+	for ; it.Next(); n++ {
+		key, id := it.Key(), it.ID()
+		if key == "" {
+			return fmt.Errorf("%v: empty key", name)
+		}
+		if n > 0 && key <= prev {
+			return fmt.Errorf("%v: key %q is not greater than the previous key %q", name, key, prev)
+		}
+		got, size, err := t.ExactMatchSearch(key)
+		if err != nil {
+			return fmt.Errorf("%v: exact match search %q, %v", name, key, err)
+		}
+		if got != id || size != len(key) {
+			return fmt.Errorf("%v: exact match search %q, expected id=%v, size=%v, got id=%v, size=%v", name, key, id, len(key), got, size)
+		}
+		ret, err := t.CommonPrefixSearch(key, 0)
+		if err != nil {
+			return fmt.Errorf("%v: common prefix search %q, %v", name, key, err)
+		}
+		if len(ret) == 0 || ret[len(ret)-1] != [2]int{id, len(key)} {
+			return fmt.Errorf("%v: common prefix search %q, missing id=%v, size=%v", name, key, id, len(key))
+		}
+		prev = key
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("%v: %v", name, err)
+	}
+	fmt.Fprintf(stdout, "%s: ok, %d keys\n", name, n)
+	return nil
+}
+
+// verifySize checks that the file consists of the blocks of the units.
+func verifySize(name string) error {
+	info, err := os.Stat(name)
+	if err != nil {
+		return err
+	}
+	layout, err := dartsclone.FileLayout(name)
+	if err != nil {
+		return err
+	}
+	return layout.CheckSize(info.Size())
+}
@@ -19,6 +19,9 @@ import (
 	"math/bits"
 )
 
+// bitUnitSize is the number of the bits in the unit of the bit vector.
+const bitUnitSize = 32
+
 type bitVector struct {
 	units   []uint32
 	ranks   []int
@@ -27,32 +30,32 @@ type bitVector struct {
 }
 
 func (v bitVector) get(id uint32) (bool, error) {
-	unitID := id / unitSize
+	unitID := id / bitUnitSize
 	if int(unitID) > len(v.units)-1 {
 		return false, fmt.Errorf("index out of bounds")
 	}
-	return (v.units[unitID] >> uint(id%unitSize) & 1) == 1, nil
+	return (v.units[unitID] >> uint(id%bitUnitSize) & 1) == 1, nil
 }
 
 func (v bitVector) rank(id uint32) (int, error) {
-	unitID := id / unitSize
+	unitID := id / bitUnitSize
 	if int(unitID) > len(v.units)-1 {
 		return -1, fmt.Errorf("index out of bounds")
 	}
-	return v.ranks[unitID] + popCount(v.units[unitID]&(^uint32(0)>>uint(unitSize-(id%unitSize)-1))), nil
+	return v.ranks[unitID] + popCount(v.units[unitID]&(^uint32(0)>>uint(bitUnitSize-(id%bitUnitSize)-1))), nil
 
 }
 
 func (v *bitVector) set(id int, bit bool) error {
-	index := id / unitSize
+	index := id / bitUnitSize
 	if index < 0 || index > len(v.units)-1 {
 		return fmt.Errorf("index out of bounds")
 	}
 	if bit {
-		v.units[index] = v.units[index] | 1<<uint(id%unitSize)
+		v.units[index] = v.units[index] | 1<<uint(id%bitUnitSize)
 		return nil
 	}
-	v.units[index] = v.units[index] & ^(1 << uint(id%unitSize))
+	v.units[index] = v.units[index] & ^(1 << uint(id%bitUnitSize))
 	return nil
 }
 
@@ -61,7 +64,7 @@ func (v bitVector) empty() bool {
 }
 
 func (v *bitVector) append() {
-	if (v.size % unitSize) == 0 {
+	if (v.size % bitUnitSize) == 0 {
 		v.units = append(v.units, 0)
 	}
 	v.size++
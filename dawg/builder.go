@@ -28,6 +28,7 @@ type Builder struct {
 	nodeStack  stack
 	recycleBin stack
 	numStates  int
+	rank       bool
 }
 
 // NewBuilder returns a DAWG builder.
@@ -46,6 +47,15 @@ func (b *Builder) init() {
 	b.nodeStack.push(0)
 }
 
+// NewRankBuilder returns a DAWG builder of the rank mode.
+// The graph of the rank mode maps the keywords to their ranks in the order of the keywords,
+// i.e. the minimal perfect hash, instead of the values, which are ignored.
+func NewRankBuilder() *Builder {
+	ret := NewBuilder()
+	ret.rank = true
+	return ret
+}
+
 // Finish finishes a building DAWG.
 func (b *Builder) Finish() (*Graph, error) {
 	if err := b.flush(0); err != nil {
@@ -54,6 +64,9 @@ func (b *Builder) Finish() (*Graph, error) {
 	b.units[0] = b.nodes[0].unit()
 	b.labels[0] = b.nodes[0].label
 	b.isIntersections.finish()
+	if b.rank {
+		b.counts = b.keyCounts()
+	}
 	b.nodes = nil
 	b.table = nil
 	b.nodeStack = nil
@@ -63,10 +76,14 @@ func (b *Builder) Finish() (*Graph, error) {
 }
 
 // Insert inserts the word and the value to a DAWG.
+// The words must be inserted in the ascending order, and the duplicated word is ignored.
 func (b *Builder) Insert(key string, value uint32) error {
 	if len(key) == 0 {
 		return fmt.Errorf("zero-length key")
 	}
+	if b.rank {
+		value = 0
+	}
 	id := 0
 	keyPos := 0
 	for ; keyPos <= len(key); keyPos++ {
@@ -103,6 +120,9 @@ func (b *Builder) Insert(key string, value uint32) error {
 		if keyPos < len(key) {
 			keyLabel = key[keyPos]
 		}
+		if keyPos < len(key) && keyLabel == 0 {
+			return fmt.Errorf("invalid null character")
+		}
 		childID, err := b.appendNode()
 		if err != nil {
 			return fmt.Errorf("append node, %v", err)
@@ -238,7 +258,7 @@ func (b *Builder) hashUnit(id int) int {
 	for ; id != 0; id++ {
 		u := b.units[id]
 		label := b.labels[id]
-		hashValue ^= b.hash((uint32(label) << 24) ^ uint32(u))
+		hashValue ^= b.hash((uint32(label) << 24) ^ uint32(u) ^ uint32(u>>32))
 		if !b.units[id].hasSibling() {
 			break
 		}
@@ -252,7 +272,7 @@ func (b *Builder) hashNode(id int) int {
 	for ; id != 0; id = b.nodes[id].sibling {
 		u := b.nodes[id].unit()
 		label := b.nodes[id].label
-		hashValue ^= b.hash((uint32(label) << 24) ^ uint32(u))
+		hashValue ^= b.hash((uint32(label) << 24) ^ uint32(u) ^ uint32(u>>32))
 	}
 	return hashValue
 }
@@ -103,6 +103,14 @@ func TestDAWGBuilder_Insert(t *testing.T) {
 		}
 	})
 
+	t.Run("null character", func(t *testing.T) {
+		b := NewBuilder()
+		b.init()
+		if err := b.Insert("a\x00b", uint32(0)); err == nil {
+			t.Error("expected invalid null character error")
+		}
+	})
+
 	t.Run("wrong key order", func(t *testing.T) {
 		b := NewBuilder()
 		b.init()
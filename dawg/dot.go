@@ -0,0 +1,129 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dawg
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// WriteDOT writes the states reachable from the state of the prefix in the Graphviz DOT language.
+// The states are named by the IDs of their first child units, the final states are the double circles
+// and the intersections, the states shared by the multiple paths, are filled.
+func (g Graph) WriteDOT(w io.Writer, prefix string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph dawg {")
+	fmt.Fprintln(bw, "\tnode [shape=circle];")
+	node, ok, err := g.dotRoot(prefix)
+	if err != nil {
+		return err
+	}
+	if ok {
+		if err := g.writeDOTStates(bw, node, prefix); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotRoot returns the unit ID of the prefix.
+func (g Graph) dotRoot(prefix string) (uint32, bool, error) {
+	if len(g.units) == 0 {
+		return 0, false, nil
+	}
+	node := g.Root()
+	for i := 0; i < len(prefix); i++ {
+		if prefix[i] == 0 {
+			return 0, false, nil
+		}
+		child, _, err := g.findChild(node, prefix[i])
+		if err != nil || child == 0 {
+			return 0, false, err
+		}
+		node = child
+	}
+	return node, true, nil
+}
+
+func (g Graph) writeDOTStates(w io.Writer, node uint32, prefix string) error {
+	root, err := g.Child(node)
+	if err != nil {
+		return err
+	}
+	if root == 0 {
+		fmt.Fprintf(w, "\ts0 [label=\"%s\"];\n", dotString(prefix))
+		return nil
+	}
+	visited := map[uint32]bool{root: true}
+	queue := []uint32{root}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		label, attrs := fmt.Sprint(state), ""
+		if state == root {
+			attrs += fmt.Sprintf(", xlabel=\"%s\"", dotString(prefix))
+		}
+		for i := state; ; i++ {
+			if int(i) >= len(g.units) {
+				return fmt.Errorf("broken graph, index out of bounds")
+			}
+			if l := g.labels[i]; l == 0 {
+				attrs += ", shape=doublecircle"
+				if g.counts == nil {
+					label += fmt.Sprintf("\\nvalue=%d", g.units[i].value())
+				}
+			} else {
+				child := g.units[i].child()
+				fmt.Fprintf(w, "\ts%d -> s%d [label=\"%s\"];\n", state, child, dotLabel(l))
+				if !visited[child] {
+					visited[child] = true
+					queue = append(queue, child)
+				}
+			}
+			if !g.units[i].hasSibling() {
+				break
+			}
+		}
+		if ok, err := g.IsIntersection(state); err != nil {
+			return err
+		} else if ok {
+			attrs += ", style=filled, fillcolor=lightgray"
+		}
+		fmt.Fprintf(w, "\ts%d [label=\"%s\"%s];\n", state, label, attrs)
+	}
+	return nil
+}
+
+// dotLabel returns the label of the edge, the printable ASCII character or the hexadecimal byte.
+func dotLabel(b byte) string {
+	if b == '"' || b == '\\' {
+		return `\` + string(b)
+	}
+	if 0x20 <= b && b < 0x7F {
+		return string(b)
+	}
+	return fmt.Sprintf("0x%02X", b)
+}
+
+// dotString returns the quoted string in the label.
+func dotString(s string) string {
+	var ret []byte
+	for i := 0; i < len(s); i++ {
+		ret = append(ret, dotLabel(s[i])...)
+	}
+	return `\"` + string(ret) + `\"`
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dawg
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGraph_WriteDOT(t *testing.T) {
+	// the suffixes "c" of "abc" and "bc" are shared.
+	g := buildGraph(t, []string{"a", "abc", "b", "bc"}, []uint32{1, 2, 1, 2})
+	testCases := []struct {
+		prefix  string
+		states  int
+		edges   int
+		finals  []string
+		shared  int
+		missing bool
+	}{
+		{prefix: "", states: 5, edges: 5, finals: []string{"value=1", "value=2"}, shared: 1},
+		{prefix: "ab", states: 2, edges: 1, finals: []string{"value=2"}, shared: 1},
+		{prefix: "x", missing: true},
+	}
+	for _, tc := range testCases {
+		var buf bytes.Buffer
+		if err := g.WriteDOT(&buf, tc.prefix); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		got := buf.String()
+		if !strings.HasPrefix(got, "digraph dawg {\n") || !strings.HasSuffix(got, "}\n") {
+			t.Errorf("%q: unexpected graph, %v", tc.prefix, got)
+		}
+		if tc.missing {
+			if strings.Contains(got, "label") {
+				t.Errorf("%q: expected no states, got %v", tc.prefix, got)
+			}
+			continue
+		}
+		if n := strings.Count(got, "->"); n != tc.edges {
+			t.Errorf("%q: expected %v edges, got %v, %v", tc.prefix, tc.edges, n, got)
+		}
+		if n := strings.Count(got, "[label=") - tc.edges; n != tc.states {
+			t.Errorf("%q: expected %v states, got %v, %v", tc.prefix, tc.states, n, got)
+		}
+		if n := strings.Count(got, "fillcolor"); n != tc.shared {
+			t.Errorf("%q: expected %v intersections, got %v, %v", tc.prefix, tc.shared, n, got)
+		}
+		for _, final := range tc.finals {
+			if !strings.Contains(got, final) {
+				t.Errorf("%q: expected %v, got %v", tc.prefix, final, got)
+			}
+		}
+	}
+}
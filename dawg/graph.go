@@ -12,6 +12,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package dawg implements the Directed Acyclic Word Graph, the minimized automaton of the words.
+// It is smaller than the double array, but the search is slower.
 package dawg
 
 import (
@@ -23,6 +25,8 @@ type Graph struct {
 	units           []unit
 	labels          []byte
 	isIntersections bitVector
+	// counts are the numbers of the keywords under the units in the rank mode, nil otherwise.
+	counts []uint32
 }
 
 // Root returns the root ID.
@@ -57,7 +61,7 @@ func (g Graph) Value(id uint32) (uint32, error) {
 	return g.units[id].value(), nil
 }
 
-// IsLeaf is true if unit has no sibling.
+// IsLeaf is true if the unit is the leaf which has the value, i.e. the label of the unit is 0.
 func (g Graph) IsLeaf(id uint32) (bool, error) {
 	l, err := g.Label(id)
 	return l == 0, err
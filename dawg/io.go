@@ -0,0 +1,199 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dawg
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// fileHeader is the header of the file of the graph, the last byte is the flags.
+const fileHeader = "DAWG\x00\x00\x00\x00"
+
+// flagRank is the flag of the rank mode.
+const flagRank = 1
+
+// WriteTo writes the graph to the writer.
+// The file starts with the header, the number of the units, the units, the labels and the bits of the intersections follow.
+// The numbers of the keywords of the rank mode are not written but counted by Read.
+func (g Graph) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var n int64
+	header := []byte(fileHeader)
+	if g.counts != nil {
+		header[len(header)-1] |= flagRank
+	}
+	c, err := bw.Write(header)
+	n += int64(c)
+	if err != nil {
+		return n, err
+	}
+	var buf [unitSize]byte
+	binary.LittleEndian.PutUint32(buf[:4], uint32(len(g.units)))
+	c, err = bw.Write(buf[:4])
+	n += int64(c)
+	if err != nil {
+		return n, err
+	}
+	for _, u := range g.units {
+		binary.LittleEndian.PutUint64(buf[:], uint64(u))
+		c, err = bw.Write(buf[:])
+		n += int64(c)
+		if err != nil {
+			return n, err
+		}
+	}
+	c, err = bw.Write(g.labels)
+	n += int64(c)
+	if err != nil {
+		return n, err
+	}
+	for i := 0; i < numBitUnits(len(g.units)); i++ {
+		var v uint32
+		if i < len(g.isIntersections.units) {
+			v = g.isIntersections.units[i]
+		}
+		binary.LittleEndian.PutUint32(buf[:4], v)
+		c, err = bw.Write(buf[:4])
+		n += int64(c)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, bw.Flush()
+}
+
+// Open opens the named file of the graph.
+func Open(name string) (*Graph, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// IsGraphFile returns true if the named file is the graph written by WriteTo.
+func IsGraphFile(name string) (bool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	var h [len(fileHeader)]byte
+	if _, err := io.ReadFull(f, h[:]); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return string(h[:len(h)-1]) == fileHeader[:len(fileHeader)-1], nil
+}
+
+// Read reads the graph written by WriteTo.
+func Read(r io.Reader) (*Graph, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(fileHeader)+4)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("invalid header, %v", err)
+	}
+	flags := header[len(fileHeader)-1]
+	if string(header[:len(fileHeader)-1]) != fileHeader[:len(fileHeader)-1] || flags&^flagRank != 0 {
+		return nil, fmt.Errorf("invalid header, not a DAWG file")
+	}
+	n := int64(binary.LittleEndian.Uint32(header[len(fileHeader):]))
+	n = int64(unitSize+1)*n + 4*((n+bitUnitSize-1)/bitUnitSize)
+	if n != int64(int(n)) {
+		return nil, fmt.Errorf("broken graph, too large size")
+	}
+	size := int(binary.LittleEndian.Uint32(header[len(fileHeader):]))
+	buf, err := readChunks(br, int(n))
+	if err != nil {
+		return nil, fmt.Errorf("broken graph, %v", err)
+	}
+	if _, err := br.ReadByte(); err != io.EOF {
+		return nil, fmt.Errorf("broken graph, trailing data")
+	}
+	g := Graph{
+		units:  make([]unit, size),
+		labels: buf[unitSize*size : (unitSize+1)*size],
+		isIntersections: bitVector{
+			units: make([]uint32, numBitUnits(size)),
+			size:  size,
+		},
+	}
+	for i := range g.units {
+		g.units[i] = unit(binary.LittleEndian.Uint64(buf[unitSize*i:]))
+	}
+	bits := buf[(unitSize+1)*size:]
+	for i := range g.isIntersections.units {
+		g.isIntersections.units[i] = binary.LittleEndian.Uint32(bits[4*i:])
+	}
+	g.isIntersections.finish()
+	if err := g.check(); err != nil {
+		return nil, err
+	}
+	if flags&flagRank != 0 {
+		g.counts = g.keyCounts()
+	}
+	return &g, nil
+}
+
+// readChunkSize is the size of the chunks read by readChunks.
+const readChunkSize = 1 << 20
+
+// readChunks reads n bytes in chunks, so the size of a broken header never allocates the memory beyond the data.
+func readChunks(r io.Reader, n int) ([]byte, error) {
+	var buf []byte
+	for len(buf) < n {
+		c := n - len(buf)
+		if c > readChunkSize {
+			c = readChunkSize
+		}
+		buf = append(buf, make([]byte, c)...)
+		if _, err := io.ReadFull(r, buf[len(buf)-c:]); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// check checks that the children of the units are in the bounds and precede the units except for the root,
+// which guarantees the graph is acyclic.
+func (g Graph) check() error {
+	if len(g.units) == 0 {
+		return nil
+	}
+	if g.units[len(g.units)-1].hasSibling() {
+		return fmt.Errorf("broken graph, the last unit has a sibling")
+	}
+	for i, u := range g.units {
+		if g.labels[i] == 0 {
+			continue
+		}
+		child := u.child()
+		if int(child) >= len(g.units) || i > 0 && int(child) >= i {
+			return fmt.Errorf("broken graph, invalid child %v of unit %v", child, i)
+		}
+	}
+	return nil
+}
+
+// numBitUnits returns the number of the units of the bit vector of the size.
+func numBitUnits(size int) int {
+	return (size + bitUnitSize - 1) / bitUnitSize
+}
@@ -0,0 +1,109 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dawg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGraph_WriteTo(t *testing.T) {
+	keys := []string{"a", "ab", "abc", "b", "bc", "電気"}
+	values := []uint32{1, 2, 3, 2, 3, 1<<31 + 5}
+	g := buildGraph(t, keys, values)
+	var buf bytes.Buffer
+	n, err := g.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("expected %v bytes, got %v", buf.Len(), n)
+	}
+	if got := g.Stats().Bytes; got != buf.Len() {
+		t.Errorf("stats: expected %v bytes, got %v", buf.Len(), got)
+	}
+
+	dir, err := ioutil.TempDir("", "dawg")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "dawg")
+	if err := ioutil.WriteFile(name, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	r, err := Open(name)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if !reflect.DeepEqual(r.Stats(), g.Stats()) {
+		t.Errorf("expected %+v, got %+v", g.Stats(), r.Stats())
+	}
+	for i, key := range keys {
+		if id, _, err := r.ExactMatchSearch(key); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		} else if id != int(values[i]) {
+			t.Errorf("%q: expected %v, got %v", key, values[i], id)
+		}
+	}
+	for i := uint32(0); i < uint32(r.Size()); i++ {
+		x, _ := g.IsIntersection(i)
+		y, _ := r.IsIntersection(i)
+		if x != y {
+			t.Errorf("intersection %v: expected %v, got %v", i, x, y)
+		}
+	}
+}
+
+func TestRead_Broken(t *testing.T) {
+	g := buildGraph(t, []string{"a", "ab", "b"}, []uint32{1, 2, 3})
+	var buf bytes.Buffer
+	if _, err := g.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	data := buf.Bytes()
+	// the child of the last unit which is not a leaf refers to the unit itself.
+	id := g.Size() - 1
+	for g.labels[id] == 0 {
+		id--
+	}
+	cyclic := append([]byte(nil), data...)
+	pos := len(fileHeader) + 4 + unitSize*id
+	binary.LittleEndian.PutUint64(cyclic[pos:], uint64(g.units[id])&3|uint64(id)<<2)
+
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "invalid header", data: append([]byte("DARTS64\x00"), data[len(fileHeader):]...)},
+		{name: "truncated", data: data[:len(data)-1]},
+		{name: "trailing data", data: append(append([]byte(nil), data...), 0)},
+		{name: "cyclic", data: cyclic},
+		{name: "too large size", data: append([]byte(fileHeader), 0xFF, 0xFF, 0xFF, 0xFF, 0)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := Read(bytes.NewReader(tc.data)); err == nil {
+				t.Errorf("expected error")
+			}
+		})
+	}
+}
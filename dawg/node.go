@@ -44,18 +44,18 @@ func (n *node) setValue(value uint32) {
 }
 
 func (n node) unit() unit {
-	hasSibling := uint32(0)
+	hasSibling := uint64(0)
 	if n.hasSibling {
 		hasSibling = 1
 	}
 	if n.label == 0 {
-		return unit((n.child << 1) | hasSibling)
+		return unit((uint64(n.child) << 1) | hasSibling)
 	}
-	isState := uint32(0)
+	isState := uint64(0)
 	if n.isState {
 		isState = 2
 	}
-	return unit((n.child << 2) | isState | hasSibling)
+	return unit((uint64(n.child) << 2) | isState | hasSibling)
 }
 
 func (n *node) String() string {
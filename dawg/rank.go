@@ -0,0 +1,60 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dawg
+
+import (
+	"fmt"
+)
+
+// IsRank is true if the graph is of the rank mode.
+func (g Graph) IsRank() bool {
+	return g.counts != nil
+}
+
+// KeyAt returns the keyword of the rank in the rank mode, the inverse of ExactMatchSearch.
+func (g Graph) KeyAt(rank int) (string, error) {
+	if g.counts == nil {
+		return "", fmt.Errorf("not the rank mode")
+	}
+	if rank < 0 || len(g.units) == 0 || rank >= int(g.counts[0]) {
+		return "", fmt.Errorf("rank out of bounds, %v", rank)
+	}
+	var key []byte
+	node := g.Root()
+	for len(key) <= len(g.units) {
+		i, err := g.Child(node)
+		if err != nil {
+			return "", err
+		}
+		for ; ; i++ {
+			if i == 0 || int(i) >= len(g.units) {
+				return "", fmt.Errorf("broken graph, index out of bounds")
+			}
+			if rank < int(g.counts[i]) {
+				break
+			}
+			rank -= int(g.counts[i])
+			if !g.units[i].hasSibling() {
+				return "", fmt.Errorf("broken graph, inconsistent counts at %v", i)
+			}
+		}
+		if g.labels[i] == 0 {
+			return string(key), nil
+		}
+		key = append(key, g.labels[i])
+		node = i
+	}
+	return "", fmt.Errorf("broken graph, cyclic path at %v", node)
+}
@@ -0,0 +1,128 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dawg
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func buildRankGraph(t *testing.T, keys []string) *Graph {
+	t.Helper()
+	b := NewRankBuilder()
+	for _, key := range keys {
+		if err := b.Insert(key, 0); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+	}
+	g, err := b.Finish()
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	return g
+}
+
+func TestGraph_Rank(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	set := map[string]bool{}
+	for i := 0; i < 3000; i++ {
+		var sb strings.Builder
+		for j := r.Intn(8) + 1; j > 0; j-- {
+			sb.WriteByte("abc\xff"[r.Intn(4)])
+		}
+		set[sb.String()] = true
+	}
+	var keys []string
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	g := buildRankGraph(t, keys)
+	var buf bytes.Buffer
+	if _, err := g.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	for name, g := range map[string]*Graph{"built": g, "read": read} {
+		t.Run(name, func(t *testing.T) {
+			if !g.IsRank() {
+				t.Fatalf("expected the rank mode")
+			}
+			for i, key := range keys {
+				if id, _, err := g.ExactMatchSearch(key); err != nil {
+					t.Fatalf("unexpected error, %v", err)
+				} else if id != i {
+					t.Fatalf("%q: expected rank %v, got %v", key, i, id)
+				}
+				if got, err := g.KeyAt(i); err != nil {
+					t.Fatalf("unexpected error, %v", err)
+				} else if got != key {
+					t.Fatalf("rank %v: expected %q, got %q", i, key, got)
+				}
+			}
+			for _, rank := range []int{-1, len(keys)} {
+				if _, err := g.KeyAt(rank); err == nil {
+					t.Errorf("rank %v: expected error", rank)
+				}
+			}
+			next := sort.SearchStrings(keys, "b")
+			if err := g.PredictiveSearchCallback("b", func(key string, id int) {
+				if key != keys[next] || id != next {
+					t.Errorf("expected (%q, %v), got (%q, %v)", keys[next], next, key, id)
+				}
+				next++
+			}); err != nil {
+				t.Errorf("unexpected error, %v", err)
+			}
+			if expected := sort.SearchStrings(keys, "c"); next != expected {
+				t.Errorf("expected %v keys, got %v", expected, next)
+			}
+			if err := g.CommonPrefixSearchCallback(keys[len(keys)-1], 0, func(id, size int) {
+				if prefix := keys[len(keys)-1][:size]; keys[id] != prefix {
+					t.Errorf("%q: expected rank of %q, got %v", prefix, prefix, id)
+				}
+			}); err != nil {
+				t.Errorf("unexpected error, %v", err)
+			}
+			if got := g.Stats().NumKeys; got != len(keys) {
+				t.Errorf("expected %v keys, got %v", len(keys), got)
+			}
+		})
+	}
+
+	values := make([]uint32, len(keys))
+	for i := range values {
+		values[i] = uint32(i)
+	}
+	if ranked, valued := g.Size(), buildGraph(t, keys, values).Size(); ranked >= valued {
+		t.Errorf("expected the graph of the rank mode smaller than %v units, got %v", valued, ranked)
+	}
+}
+
+func TestGraph_KeyAt(t *testing.T) {
+	if _, err := buildGraph(t, []string{"a"}, []uint32{0}).KeyAt(0); err == nil {
+		t.Errorf("expected error of the graph not of the rank mode")
+	}
+	if _, err := buildRankGraph(t, nil).KeyAt(0); err == nil {
+		t.Errorf("expected error of the empty graph")
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dawg
+
+import (
+	"fmt"
+)
+
+// findChild returns the child unit ID of a unit which has the label, or 0 if not found.
+// The skipped is the number of the keywords under the preceding siblings of the child in the rank mode.
+func (g Graph) findChild(id uint32, label byte) (child uint32, skipped int, err error) {
+	first, err := g.Child(id)
+	if err != nil || first == 0 {
+		return 0, 0, err
+	}
+	for i := first; ; i++ {
+		if int(i) >= len(g.units) {
+			return 0, 0, fmt.Errorf("broken graph, index out of bounds")
+		}
+		if l := g.labels[i]; l == label {
+			return i, skipped, nil
+		} else if l > label {
+			return 0, 0, nil
+		}
+		if !g.units[i].hasSibling() {
+			return 0, 0, nil
+		}
+		if g.counts != nil {
+			skipped += int(g.counts[i])
+		}
+	}
+}
+
+// leafID returns the id of the keyword of the leaf, the rank in the rank mode or the value.
+func (g Graph) leafID(leaf uint32, rank int) int {
+	if g.counts != nil {
+		return rank
+	}
+	return int(g.units[leaf].value())
+}
+
+// ExactMatchSearch searches the graph by a given keyword and returns the value, or the rank in the rank mode, and it's length if found.
+func (g Graph) ExactMatchSearch(key string) (id, size int, err error) {
+	if len(g.units) == 0 {
+		return -1, 0, nil
+	}
+	node, rank := g.Root(), 0
+	for i := 0; i < len(key); i++ {
+		if key[i] == 0 {
+			return -1, 0, nil
+		}
+		child, skipped, err := g.findChild(node, key[i])
+		if err != nil {
+			return -1, -1, err
+		} else if child == 0 {
+			return -1, 0, nil
+		}
+		node, rank = child, rank+skipped
+	}
+	leaf, _, err := g.findChild(node, 0)
+	if err != nil {
+		return -1, -1, err
+	} else if leaf == 0 {
+		return -1, 0, nil
+	}
+	return g.leafID(leaf, rank), len(key), nil
+}
+
+// CommonPrefixSearch finds keywords sharing common prefix in an input and returns the array of pairs (value and it's length) if found.
+func (g Graph) CommonPrefixSearch(key string, offset int) ([][2]int, error) {
+	var ret [][2]int
+	err := g.CommonPrefixSearchCallback(key, offset, func(id, size int) {
+		ret = append(ret, [2]int{id, size})
+	})
+	return ret, err
+}
+
+// CommonPrefixSearchCallback finds keywords sharing common prefix in an input and callback with value and it's length.
+func (g Graph) CommonPrefixSearchCallback(key string, offset int, callback func(id, size int)) error {
+	if len(g.units) == 0 {
+		return nil
+	}
+	node, rank := g.Root(), 0
+	for i := offset; i < len(key); i++ {
+		if key[i] == 0 {
+			break
+		}
+		child, skipped, err := g.findChild(node, key[i])
+		if err != nil {
+			return err
+		} else if child == 0 {
+			break
+		}
+		node, rank = child, rank+skipped
+		leaf, _, err := g.findChild(node, 0)
+		if err != nil {
+			return err
+		}
+		if leaf != 0 {
+			callback(g.leafID(leaf, rank), i+1)
+		}
+	}
+	return nil
+}
+
+// PredictiveSearchCallback finds keywords starting with the prefix and callback with the keyword and it's value in the order of the keywords.
+// All keywords of the graph are enumerated by the empty prefix.
+func (g Graph) PredictiveSearchCallback(prefix string, callback func(key string, id int)) error {
+	if len(g.units) == 0 {
+		return nil
+	}
+	node, rank := g.Root(), 0
+	for i := 0; i < len(prefix); i++ {
+		if prefix[i] == 0 {
+			return nil
+		}
+		child, skipped, err := g.findChild(node, prefix[i])
+		if err != nil {
+			return err
+		} else if child == 0 {
+			return nil
+		}
+		node, rank = child, rank+skipped
+	}
+	return g.enumerate(node, []byte(prefix), &rank, callback)
+}
+
+// enumerate calls the callback with the keywords under the unit in the order of the keywords.
+// The rank is the rank of the next keyword.
+func (g Graph) enumerate(id uint32, key []byte, rank *int, callback func(key string, id int)) error {
+	if len(key) > len(g.units) {
+		return fmt.Errorf("broken graph, cyclic path at %v", id)
+	}
+	child, err := g.Child(id)
+	if err != nil || child == 0 {
+		return err
+	}
+	for i := child; ; i++ {
+		if int(i) >= len(g.units) {
+			return fmt.Errorf("broken graph, index out of bounds")
+		}
+		if label := g.labels[i]; label == 0 {
+			callback(string(key), g.leafID(i, *rank))
+			*rank++
+		} else if err := g.enumerate(i, append(key, label), rank, callback); err != nil {
+			return err
+		}
+		if !g.units[i].hasSibling() {
+			return nil
+		}
+	}
+}
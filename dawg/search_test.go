@@ -0,0 +1,180 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dawg
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func buildGraph(t *testing.T, keys []string, values []uint32) *Graph {
+	t.Helper()
+	b := NewBuilder()
+	for i, key := range keys {
+		if err := b.Insert(key, values[i]); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+	}
+	g, err := b.Finish()
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	return g
+}
+
+func TestGraph_ExactMatchSearch(t *testing.T) {
+	g := buildGraph(t, []string{"a", "abc", "b", "bc", "電気"}, []uint32{1, 2, 1, 2, 3})
+	testCases := []struct {
+		key  string
+		id   int
+		size int
+	}{
+		{key: "a", id: 1, size: 1},
+		{key: "abc", id: 2, size: 3},
+		{key: "b", id: 1, size: 1},
+		{key: "bc", id: 2, size: 2},
+		{key: "電気", id: 3, size: len("電気")},
+		{key: "", id: -1, size: 0},
+		{key: "ab", id: -1, size: 0},
+		{key: "abcd", id: -1, size: 0},
+		{key: "a\x00", id: -1, size: 0},
+		{key: "c", id: -1, size: 0},
+	}
+	for _, tc := range testCases {
+		id, size, err := g.ExactMatchSearch(tc.key)
+		if err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if id != tc.id || size != tc.size {
+			t.Errorf("%q: expected (%v, %v), got (%v, %v)", tc.key, tc.id, tc.size, id, size)
+		}
+	}
+}
+
+func TestGraph_CommonPrefixSearch(t *testing.T) {
+	g := buildGraph(t, []string{"a", "ab", "abcd", "b"}, []uint32{1, 2, 3, 4})
+	testCases := []struct {
+		key      string
+		offset   int
+		expected [][2]int
+	}{
+		{key: "abcde", expected: [][2]int{{1, 1}, {2, 2}, {3, 4}}},
+		{key: "xabcde", offset: 1, expected: [][2]int{{1, 2}, {2, 3}, {3, 5}}},
+		{key: "a\x00b", expected: [][2]int{{1, 1}}},
+		{key: "c", expected: nil},
+	}
+	for _, tc := range testCases {
+		got, err := g.CommonPrefixSearch(tc.key, tc.offset)
+		if err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%q: expected %v, got %v", tc.key, tc.expected, got)
+		}
+	}
+}
+
+func TestGraph_PredictiveSearchCallback(t *testing.T) {
+	keys := []string{"a", "ab", "abc", "b", "bc"}
+	g := buildGraph(t, keys, []uint32{1, 2, 3, 2, 3})
+	testCases := []struct {
+		prefix   string
+		expected []string
+	}{
+		{prefix: "", expected: []string{"a:1", "ab:2", "abc:3", "b:2", "bc:3"}},
+		{prefix: "ab", expected: []string{"ab:2", "abc:3"}},
+		{prefix: "bc", expected: []string{"bc:3"}},
+		{prefix: "c", expected: nil},
+	}
+	for _, tc := range testCases {
+		var got []string
+		err := g.PredictiveSearchCallback(tc.prefix, func(key string, id int) {
+			got = append(got, key+":"+string(rune('0'+id)))
+		})
+		if err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("%q: expected %v, got %v", tc.prefix, tc.expected, got)
+		}
+	}
+}
+
+func TestGraph_Empty(t *testing.T) {
+	g := buildGraph(t, nil, nil)
+	if id, _, err := g.ExactMatchSearch("a"); err != nil || id != -1 {
+		t.Errorf("expected not found, got %v, %v", id, err)
+	}
+	if ret, err := g.CommonPrefixSearch("a", 0); err != nil || len(ret) != 0 {
+		t.Errorf("expected not found, got %v, %v", ret, err)
+	}
+	if err := g.PredictiveSearchCallback("", func(key string, id int) {
+		t.Errorf("unexpected key %q", key)
+	}); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if got := g.Stats().NumKeys; got != 0 {
+		t.Errorf("expected no keys, got %v", got)
+	}
+}
+
+func TestGraph_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	dic := map[string]uint32{}
+	for i := 0; i < 3000; i++ {
+		var sb strings.Builder
+		for j := r.Intn(8) + 1; j > 0; j-- {
+			sb.WriteByte("abc\xff"[r.Intn(4)])
+		}
+		dic[sb.String()] = uint32(r.Intn(4))
+	}
+	var keys []string
+	for k := range dic {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	values := make([]uint32, len(keys))
+	for i, k := range keys {
+		values[i] = dic[k]
+	}
+	g := buildGraph(t, keys, values)
+	for i, key := range keys {
+		if id, size, err := g.ExactMatchSearch(key); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		} else if id != int(values[i]) || size != len(key) {
+			t.Fatalf("%q: expected (%v, %v), got (%v, %v)", key, values[i], len(key), id, size)
+		}
+	}
+	var got []string
+	if err := g.PredictiveSearchCallback("", func(key string, id int) {
+		if uint32(id) != dic[key] {
+			t.Errorf("%q: expected %v, got %v", key, dic[key], id)
+		}
+		got = append(got, key)
+	}); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if !reflect.DeepEqual(got, keys) {
+		t.Errorf("expected %v keys, got %v", len(keys), len(got))
+	}
+	if stats := g.Stats(); stats.NumKeys != len(keys) {
+		t.Errorf("expected %v keys, got %v", len(keys), stats.NumKeys)
+	} else if stats.NumIntersections == 0 {
+		t.Errorf("expected the minimized graph, got no intersections")
+	}
+}
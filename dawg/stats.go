@@ -0,0 +1,74 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dawg
+
+// Stats represents the size statistics of the graph.
+type Stats struct {
+	// NumKeys is the number of the keywords.
+	NumKeys int
+	// NumUnits is the number of the units.
+	NumUnits int
+	// NumIntersections is the number of the units shared by the multiple paths.
+	NumIntersections int
+	// Bytes is the size of the graph written by WriteTo.
+	Bytes int
+}
+
+// Stats returns the size statistics of the graph.
+func (g Graph) Stats() Stats {
+	return Stats{
+		NumKeys:          g.numKeys(),
+		NumUnits:         len(g.units),
+		NumIntersections: g.NumIntersections(),
+		Bytes:            len(fileHeader) + 4 + (unitSize+1)*len(g.units) + 4*numBitUnits(len(g.units)),
+	}
+}
+
+// numKeys returns the number of the keywords.
+func (g Graph) numKeys() int {
+	if len(g.units) == 0 {
+		return 0
+	}
+	if g.counts != nil {
+		return int(g.counts[0])
+	}
+	return int(g.keyCounts()[0])
+}
+
+// keyCounts returns the numbers of the keywords under the units in the order of the units,
+// the children of a unit precede the unit except for the root.
+func (g Graph) keyCounts() []uint32 {
+	counts := make([]uint32, len(g.units))
+	countChildren := func(id int) uint32 {
+		if g.labels[id] == 0 {
+			return 1
+		}
+		var n uint32
+		for i := int(g.units[id].child()); 0 < i && i < len(g.units) && (i < id || id == 0); i++ {
+			n += counts[i]
+			if !g.units[i].hasSibling() {
+				break
+			}
+		}
+		return n
+	}
+	for i := 1; i < len(g.units); i++ {
+		counts[i] = countChildren(i)
+	}
+	if len(g.units) > 0 {
+		counts[0] = countChildren(0)
+	}
+	return counts
+}
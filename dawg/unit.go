@@ -14,24 +14,25 @@
 
 package dawg
 
-const (
-	unitSize = 32
-)
+import "unsafe"
 
-type unit uint32
+// unitSize is the size of the unit in bytes.
+const unitSize = int(unsafe.Sizeof(unit(0)))
+
+type unit uint64
 
 func (u unit) child() uint32 {
-	return uint32(u) >> 2
+	return uint32(u >> 2)
 }
 
 func (u unit) hasSibling() bool {
-	return (uint32(u) & 1) == 1
+	return (u & 1) == 1
 }
 
 func (u unit) value() uint32 {
-	return uint32(u) >> 1
+	return uint32(u >> 1)
 }
 
 func (u unit) isState() bool {
-	return (uint32(u) & 2) == 2
+	return (u & 2) == 2
 }
@@ -0,0 +1,39 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"fmt"
+	"io"
+)
+
+// dotWriter is the TRIE which writes the units in the Graphviz DOT language.
+type dotWriter interface {
+	WriteDOT(w io.Writer, prefix string) error
+}
+
+// WriteDOT writes the units of the TRIE reachable from the node of the prefix in the Graphviz DOT language,
+// the nodes are the units named by the indexes and the leaf units are the boxes with the ids.
+// The empty prefix writes all units, which is too large to render for a large TRIE.
+func WriteDOT(w io.Writer, t Trie, prefix string) error {
+	if h, ok := t.(heapTrie); ok {
+		t = h.Trie
+	}
+	d, ok := t.(dotWriter)
+	if !ok {
+		return fmt.Errorf("DOT is not supported by %T", t)
+	}
+	return d.WriteDOT(w, prefix)
+}
@@ -0,0 +1,55 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	f, err := ioutil.TempFile("", "dot_test")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer os.Remove(f.Name())
+	b := NewBuilder(nil)
+	if err := b.Build([]string{"hello", "world"}, nil); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if _, err := b.WriteTo(f); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	f.Close()
+
+	trie, err := OpenAuto(f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer trie.Close()
+	var buf bytes.Buffer
+	if err := WriteDOT(&buf, trie, "w"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if got := buf.String(); !strings.Contains(got, "id=1") || strings.Contains(got, "id=0") {
+		t.Errorf("unexpected graph, %v", got)
+	}
+	if err := WriteDOT(&buf, NewOverlayTrie(trie), ""); err == nil {
+		t.Errorf("expected error of the TRIE which does not support DOT")
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"fmt"
+	"io"
+)
+
+// unitDumper is the TRIE which writes the decoded units.
+type unitDumper interface {
+	DumpUnits(w io.Writer) error
+}
+
+// DumpUnits writes the units of the TRIE decoded one per line for debugging,
+// the node units with the labels, the offsets and the prefixes, the leaf units with the values and the keys,
+// the filler units which are unreachable from the root and the anomalies, e.g. the offsets out of range.
+// The last line is the summary of the numbers of the units.
+func DumpUnits(w io.Writer, t Trie) error {
+	if h, ok := t.(heapTrie); ok {
+		t = h.Trie
+	}
+	d, ok := t.(unitDumper)
+	if !ok {
+		return fmt.Errorf("dump of the units is not supported by %T", t)
+	}
+	return d.DumpUnits(w)
+}
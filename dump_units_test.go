@@ -0,0 +1,38 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDumpUnits(t *testing.T) {
+	trie, err := BuildTRIE([]string{"hello", "world"}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	var buf bytes.Buffer
+	if err := DumpUnits(&buf, trie); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if got := buf.String(); !strings.Contains(got, "key=\"world\"") || !strings.Contains(got, "anomalies 0\n") {
+		t.Errorf("unexpected units, %v", got)
+	}
+	if err := DumpUnits(&buf, NewOverlayTrie(trie)); err == nil {
+		t.Errorf("expected error of the TRIE which does not support the dump of the units")
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"fmt"
+
+	"github.com/ikawaha/dartsclone/internal"
+)
+
+var (
+	// ErrKeyExists is the error returned by the insertion of the key which is already in the TRIE.
+	ErrKeyExists = internal.ErrKeyExists
+	// ErrKeyNotFound is the error returned by the modification of the key which is not in the TRIE.
+	ErrKeyNotFound = internal.ErrKeyNotFound
+)
+
+// DynamicTrie represents the TRIE which supports the insertions, the deletions and the updates of the keys.
+// The id of a key is the value given by Insert or Update.
+// It is safe to search concurrently with the modifications.
+type DynamicTrie struct {
+	*internal.DynamicDoubleArray
+}
+
+// NewDynamicTrie returns the empty dynamic TRIE.
+func NewDynamicTrie() *DynamicTrie {
+	return &DynamicTrie{
+		DynamicDoubleArray: internal.NewDynamicDoubleArray(),
+	}
+}
+
+// NewDynamicTrieFrom returns the dynamic TRIE of the keys and the ids of the TRIE.
+func NewDynamicTrieFrom(t Trie) (*DynamicTrie, error) {
+	ret := NewDynamicTrie()
+	it := Keys(t, "")
+	defer it.Close()
+	for it.Next() {
+		if err := ret.Insert(it.Key(), uint32(it.ID())); err != nil {
+			return nil, fmt.Errorf("insert %v, %v", it.Key(), err)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Compact builds the static TRIE of the keys and the ids, which is written by WriteTo of the builder.
+func (t DynamicTrie) Compact(progress ProgressFunction) (*Builder, error) {
+	b, err := t.DynamicDoubleArray.Compact(progress)
+	if err != nil {
+		return nil, err
+	}
+	return &Builder{DoubleArrayBuilder: b}, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDynamicTrie(t *testing.T) {
+	base, err := BuildTRIE([]string{"a", "ab", "abc"}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	d, err := NewDynamicTrieFrom(base)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if err := d.Insert("b", 3); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if err := d.Insert("a", 3); err != ErrKeyExists {
+		t.Errorf("expected ErrKeyExists, got %v", err)
+	}
+	if err := d.Delete("ab"); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if err := d.Update("ab", 1); err != ErrKeyNotFound {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+
+	var trie Trie = d
+	ret, err := trie.CommonPrefixSearch("abc", 0)
+	if err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if expected := [][2]int{{0, 1}, {2, 3}}; !reflect.DeepEqual(expected, ret) {
+		t.Errorf("expected %v, got %v", expected, ret)
+	}
+
+	b, err := d.Compact(nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	a, err := b.DoubleArrayUint32()
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	for key, expected := range map[string]int{"a": 0, "ab": -1, "abc": 2, "b": 3} {
+		if id, _, err := a.ExactMatchSearch(key); err != nil || id != expected {
+			t.Errorf("expected id=%v, got id=%v, err=%v (%v)", expected, id, err, key)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 module github.com/ikawaha/dartsclone
 
+go 1.19
+
 require (
 	github.com/euclidr/darts v0.0.0-20180401113647-e0859b23b68e
 	github.com/ikawaha/da v0.0.0-20141126165404-5556b9e515ca
 	github.com/schollz/progressbar/v2 v2.7.1
-	golang.org/x/sys v0.0.0-20181213200352-4d1cda033e06
+	golang.org/x/sys v0.15.0
 )
+
+require github.com/mitchellh/colorstring v0.0.0-20150917214807-8631ce90f286 // indirect
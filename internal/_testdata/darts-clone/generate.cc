@@ -0,0 +1,78 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// generate builds the golden file of the reference C++ darts-clone
+// (https://github.com/s-yata/darts-clone) from a key file for the compatibility tests.
+// Each line of the key file is a key, or a key and a value separated by a tab,
+// and the keys are sorted in the byte order.
+//
+//	g++ -O2 -I <darts-clone>/include -o generate generate.cc
+//	./generate no_values.txt no_values.darts
+//	./generate values.txt values.darts
+//
+// The golden files must be generated on a little-endian machine,
+// Darts::DoubleArray::save writes the units in the native byte order.
+
+#include <darts.h>
+
+#include <cstdlib>
+#include <fstream>
+#include <iostream>
+#include <string>
+#include <vector>
+
+int main(int argc, char *argv[]) {
+  if (argc != 3) {
+    std::cerr << "Usage: generate <key file> <darts-clone file>" << std::endl;
+    return 2;
+  }
+  std::ifstream in(argv[1]);
+  if (!in) {
+    std::cerr << "failed to open " << argv[1] << std::endl;
+    return 1;
+  }
+  std::vector<std::string> keys;
+  std::vector<Darts::DoubleArray::value_type> values;
+  std::string line;
+  while (std::getline(in, line)) {
+    if (line.empty()) {
+      continue;
+    }
+    std::string::size_type tab = line.rfind('\t');
+    if (tab != std::string::npos) {
+      values.push_back(std::atoi(line.c_str() + tab + 1));
+      line.resize(tab);
+    }
+    keys.push_back(line);
+  }
+  if (!values.empty() && values.size() != keys.size()) {
+    std::cerr << "all lines must have values or no lines have values" << std::endl;
+    return 1;
+  }
+  std::vector<const char *> ptrs;
+  for (std::size_t i = 0; i < keys.size(); ++i) {
+    ptrs.push_back(keys[i].c_str());
+  }
+
+  Darts::DoubleArray da;
+  if (da.build(ptrs.size(), &ptrs[0], NULL, values.empty() ? NULL : &values[0]) != 0) {
+    std::cerr << "failed to build" << std::endl;
+    return 1;
+  }
+  if (da.save(argv[2]) != 0) {
+    std::cerr << "failed to save " << argv[2] << std::endl;
+    return 1;
+  }
+  return 0;
+}
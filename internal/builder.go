@@ -15,11 +15,14 @@
 package internal
 
 import (
+	"context"
 	"encoding/binary"
 	"fmt"
 	"io"
+	"runtime"
+	"time"
 
-	"github.com/ikawaha/dartsclone/internal/dawg"
+	"github.com/ikawaha/dartsclone/dawg"
 )
 
 const (
@@ -27,6 +30,9 @@ const (
 	numExtraBlocks = 16
 	numExtras      = blockSize * numExtraBlocks
 
+	// contextCheckInterval is the number of steps between checks of the context cancellation.
+	contextCheckInterval = 1 << 10
+
 	upperMask = 0xFF << 21
 	lowerMask = 0xFF
 )
@@ -41,12 +47,25 @@ type extraUnit struct {
 // DoubleArrayBuilder represents the builder of the double array.
 type DoubleArrayBuilder struct {
 	units      []unit
+	units64    []unit64
 	extras     []extraUnit
 	labels     []byte
 	table      []int
 	extrasHead int
 
-	progress ProgressFunction
+	// isUint64 is true if the builder arranges units in the 64-bit layout.
+	isUint64 bool
+	overflow bool
+
+	ctx context.Context
+	// canceled is the error of the context which stopped the build, nil otherwise.
+	canceled error
+	steps    int
+	stats    BuildStats
+
+	progress PhaseProgressFunction
+	// progressNodes reports the progress of the arrangement per DAWG node.
+	progressNodes bool
 }
 
 // BuildDoubleArray constructs a double array from given keywords and values.
@@ -56,39 +75,329 @@ func BuildDoubleArray(keys []string, values []uint32, progress ProgressFunction)
 	if err := b.Build(keys, values); err != nil {
 		return nil, fmt.Errorf("build error, %v", err)
 	}
-	return &DoubleArrayUint32{array: b.toArray()}, nil
+	return b.DoubleArrayUint32()
+}
+
+// BuildDoubleArrayUint64 constructs a double array of the 64-bit layout from given keywords and values.
+// The parameter values sets nil if no values.
+func BuildDoubleArrayUint64(keys []string, values []uint32, progress ProgressFunction) (*DoubleArrayUint64, error) {
+	b := NewDoubleArrayBuilder(progress)
+	b.isUint64 = true
+	if err := b.Build(keys, values); err != nil {
+		return nil, fmt.Errorf("build error, %v", err)
+	}
+	return b.DoubleArrayUint64()
 }
 
 // NewDoubleArrayBuilder returns a builder of the double array with progress function.
 // The parameter progress sets nil if no progress bar.
+// If the progress implements PhaseProgressFunction, the builder reports the progress of each phase to it.
 func NewDoubleArrayBuilder(progress ProgressFunction) *DoubleArrayBuilder {
 	return &DoubleArrayBuilder{
-		progress: progress,
+		progress: NewPhaseProgressFunction(progress),
 	}
 }
 
 // Build constructs a double array from given keys and values.
+// The 64-bit layout is selected automatically if the 32-bit layout cannot hold the offsets or the values.
 func (b *DoubleArrayBuilder) Build(keys []string, values []uint32) error {
-	keySet, err := newSortedKeySet(keys, values)
+	return b.BuildContext(context.Background(), keys, values)
+}
+
+// BuildContext constructs a double array from given keys and values.
+// If the context is canceled during the build, BuildContext returns ctx.Err().
+func (b *DoubleArrayBuilder) BuildContext(ctx context.Context, keys []string, values []uint32) error {
+	b.stats = BuildStats{}
+	start := time.Now()
+	keySet, err := b.sortKeys(keys, values)
 	if err != nil {
 		return fmt.Errorf("build key set, %v", err)
 	}
+	b.stats.SortTime = time.Since(start)
+	return b.buildKeySet(ctx, keySet, b.build)
+}
+
+// BuildParallel constructs a double array from given keys and values using the parallelism number of goroutines.
+// The keys are partitioned by the first byte and the DAWGs of the partitions are built concurrently.
+// The search results are identical to Build, but the double array may be slightly larger
+// because suffixes are not shared across the partitions.
+// The parameter parallelism sets 0 to use GOMAXPROCS.
+func (b *DoubleArrayBuilder) BuildParallel(keys []string, values []uint32, parallelism int) error {
+	return b.BuildParallelContext(context.Background(), keys, values, parallelism)
+}
+
+// BuildParallelContext constructs a double array from given keys and values using the parallelism number of goroutines.
+// If the context is canceled during the build, BuildParallelContext returns ctx.Err().
+func (b *DoubleArrayBuilder) BuildParallelContext(ctx context.Context, keys []string, values []uint32, parallelism int) error {
+	b.stats = BuildStats{}
+	start := time.Now()
+	s, err := b.sortKeys(keys, values)
+	if err != nil {
+		return fmt.Errorf("build key set, %v", err)
+	}
+	b.stats.SortTime = time.Since(start)
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	return b.buildKeySet(ctx, s, func(keySet *keySet) error {
+		return b.buildParallel(keySet, parallelism)
+	})
+}
+
+// KeySource is the stream of the keys in the ascending order and their values.
+type KeySource interface {
+	// Next advances to the next key, it returns false at the end of the keys or on an error.
+	Next() bool
+	// Key returns the current key.
+	Key() string
+	// Value returns the value of the current key.
+	Value() uint32
+	// Err returns the error occurred while reading the keys.
+	Err() error
+}
+
+// BuildStream constructs a double array from the keys and the values read from the source,
+// which are inserted into the DAWG one by one, so the keys are never held on the memory.
+// The keys must be sorted in the ascending order without duplicates.
+func (b *DoubleArrayBuilder) BuildStream(src KeySource) error {
+	return b.BuildStreamContext(context.Background(), src)
+}
+
+// BuildStreamContext constructs a double array from the keys and the values read from the source.
+// If the context is canceled during the build, BuildStreamContext returns ctx.Err().
+func (b *DoubleArrayBuilder) BuildStreamContext(ctx context.Context, src KeySource) error {
+	b.stats = BuildStats{}
+	b.ctx = ctx
+	b.canceled = nil
+	b.steps = 0
+	defer func() {
+		b.ctx = nil
+	}()
+	start := time.Now()
+	// the number of the keys is unknown until the end of the stream.
+	b.startPhase(PhaseDAWG, 0)
+	g, stats, err := b.buildStreamDAWG(src)
+	if err != nil && b.canceled != nil {
+		return b.canceled
+	} else if err != nil {
+		return fmt.Errorf("build DAWG, %v", err)
+	}
+	b.finishPhase(PhaseDAWG)
+	if stats.large {
+		b.isUint64 = true
+	}
+	dawgTime := time.Since(start)
+	err = b.buildFromDAWG(g)
+	if err != nil && b.overflow && !b.isUint64 {
+		b.reset()
+		b.isUint64 = true
+		err = b.buildFromDAWG(g)
+	}
+	if err != nil && b.canceled != nil {
+		return b.canceled
+	} else if err != nil {
+		return err
+	}
+	b.stats.DAWGTime = dawgTime
+	b.finishStats(stats.numKeys, stats.keyBytes)
+	return nil
+}
+
+// streamStats represents the numbers of the keys read from the source.
+type streamStats struct {
+	numKeys  int
+	keyBytes int64
+	// large is true if a value does not fit in the 32-bit layout.
+	large bool
+}
+
+func (b *DoubleArrayBuilder) buildStreamDAWG(src KeySource) (*dawg.Graph, streamStats, error) {
+	var stats streamStats
+	var prev string
+	dawgBuilder := dawg.NewBuilder()
+	for src.Next() {
+		if stats.numKeys%contextCheckInterval == 0 {
+			if err := b.contextErr(); err != nil {
+				return nil, stats, err
+			}
+		}
+		k, v := src.Key(), src.Value()
+		if stats.numKeys > 0 && k <= prev {
+			if k == prev {
+				return nil, stats, fmt.Errorf("duplicate key error, %v", k)
+			}
+			return nil, stats, fmt.Errorf("wrong key order, %v", k)
+		}
+		prev = k
+		if err := dawgBuilder.Insert(k, v); err != nil {
+			return nil, stats, fmt.Errorf("DAWG builder insert %v, %v", k, err)
+		}
+		stats.numKeys++
+		stats.keyBytes += int64(len(k))
+		if v >= 1<<31 {
+			stats.large = true
+		}
+		// progress bar
+		b.incrementProgress(1)
+	}
+	if err := src.Err(); err != nil {
+		return nil, stats, fmt.Errorf("read keys, %v", err)
+	}
+	g, err := dawgBuilder.Finish()
+	if err != nil {
+		return nil, stats, fmt.Errorf("DAWG builder finish, %v", err)
+	}
+	return g, stats, nil
+}
+
+func (b DoubleArrayBuilder) sortKeys(keys []string, values []uint32) (*keySet, error) {
+	b.startPhase(PhaseSort, len(keys))
+	keySet, err := newSortedKeySet(keys, values)
+	if err != nil {
+		return nil, err
+	}
+	b.incrementProgress(len(keys))
+	b.finishPhase(PhaseSort)
+	return keySet, nil
+}
+
+func (b *DoubleArrayBuilder) buildKeySet(ctx context.Context, keySet *keySet, build func(*keySet) error) error {
+	b.ctx = ctx
+	b.canceled = nil
+	b.steps = 0
+	defer func() {
+		b.ctx = nil
+	}()
+	if !b.isUint64 && !keySet.fitsUint32() {
+		b.isUint64 = true
+	}
+	err := build(keySet)
+	if err != nil && b.overflow && !b.isUint64 {
+		b.reset()
+		b.isUint64 = true
+		err = build(keySet)
+	}
+	if err != nil && b.canceled != nil {
+		return b.canceled
+	}
+	if err == nil {
+		var keyBytes int64
+		for _, k := range keySet.keys {
+			keyBytes += int64(len(k))
+		}
+		b.finishStats(keySet.size(), keyBytes)
+	}
+	return err
+}
+
+func (b DoubleArrayBuilder) startPhase(name string, total int) {
+	if b.progress != nil {
+		b.progress.StartPhase(name, total)
+	}
+}
+
+func (b DoubleArrayBuilder) incrementProgress(n int) {
+	if b.progress != nil {
+		b.progress.IncrementBy(n)
+	}
+}
+
+func (b DoubleArrayBuilder) finishPhase(name string) {
+	if b.progress != nil {
+		b.progress.FinishPhase(name)
+	}
+}
+
+// checkContext returns the error of the context once every contextCheckInterval steps.
+func (b *DoubleArrayBuilder) checkContext() error {
+	b.steps++
+	if b.steps%contextCheckInterval != 0 {
+		return nil
+	}
+	return b.contextErr()
+}
+
+// contextErr returns the error of the context and records it,
+// so the build returns it unwrapped instead of the error wrapping it.
+func (b *DoubleArrayBuilder) contextErr() error {
+	if b.ctx == nil {
+		return nil
+	}
+	if err := b.ctx.Err(); err != nil {
+		b.canceled = err
+		return err
+	}
+	return nil
+}
+
+// IsUint64 returns true if the double array is arranged in the 64-bit layout.
+func (b DoubleArrayBuilder) IsUint64() bool {
+	return b.isUint64
+}
+
+// DoubleArrayUint32 returns the double array built by the builder.
+func (b DoubleArrayBuilder) DoubleArrayUint32() (*DoubleArrayUint32, error) {
+	if b.isUint64 {
+		return nil, fmt.Errorf("the double array is arranged in the 64-bit layout")
+	}
+	return &DoubleArrayUint32{array: b.toArray()}, nil
+}
+
+// DoubleArrayUint64 returns the double array of the 64-bit layout built by the builder.
+func (b DoubleArrayBuilder) DoubleArrayUint64() (*DoubleArrayUint64, error) {
+	if !b.isUint64 {
+		return nil, fmt.Errorf("the double array is arranged in the 32-bit layout")
+	}
+	return &DoubleArrayUint64{array: b.toArray64()}, nil
+}
+
+func (b *DoubleArrayBuilder) reset() {
+	b.units = nil
+	b.units64 = nil
+	b.extras = nil
+	b.labels = nil
+	b.table = nil
+	b.extrasHead = 0
+	b.overflow = false
+	b.stats = BuildStats{SortTime: b.stats.SortTime}
+}
+
+func (b *DoubleArrayBuilder) build(keySet *keySet) error {
 	if !keySet.hasValues() {
+		start := time.Now()
+		b.startPhase(PhaseArrange, keySet.size())
 		if err := b.buildFromKeySetHeader(keySet); err != nil {
 			return fmt.Errorf("build from key set header, %v", err)
 		}
+		b.stats.ArrangeTime = time.Since(start) - b.stats.FixBlocksTime
 		return nil
 	}
-	if b.progress != nil {
-		b.progress.SetMaximum(keySet.Len())
-	}
+	start := time.Now()
+	b.startPhase(PhaseDAWG, keySet.size())
 	g, err := b.buildDAWG(keySet)
 	if err != nil {
 		return fmt.Errorf("build DAWG, %v", err)
 	}
-	if err := b.buildFromDAWGHeader(g); err != nil {
+	b.finishPhase(PhaseDAWG)
+	b.stats.DAWGTime = time.Since(start)
+	return b.buildFromDAWG(g)
+}
+
+// buildFromDAWG arranges the units of the DAWG.
+func (b *DoubleArrayBuilder) buildFromDAWG(g *dawg.Graph) error {
+	b.stats.NumDAWGNodes = g.Size()
+	b.stats.NumIntersections = g.NumIntersections()
+
+	start := time.Now()
+	// the units of the DAWG except the root are arranged once.
+	b.startPhase(PhaseArrange, g.Size()-1)
+	b.progressNodes = true
+	err := b.buildFromDAWGHeader(g)
+	b.progressNodes = false
+	if err != nil {
 		return fmt.Errorf("build from DAWG header, %v", err)
 	}
+	b.stats.ArrangeTime = time.Since(start) - b.stats.FixBlocksTime
 	return nil
 }
 
@@ -100,29 +409,117 @@ func (b DoubleArrayBuilder) toArray() []uint32 {
 	return ret
 }
 
+func (b DoubleArrayBuilder) toArray64() []uint64 {
+	var ret []uint64
+	for _, u := range b.units64 {
+		ret = append(ret, uint64(u))
+	}
+	return ret
+}
+
 // WriteTo write to the serialize data of the double array.
+// The data of the 64-bit layout starts with the header which identifies the layout.
 func (b DoubleArrayBuilder) WriteTo(w io.Writer) (int64, error) {
 	var size int64
-	for _, v := range b.units {
+	b.startPhase(PhaseWrite, (b.numUnits()+blockSize-1)/blockSize)
+	if b.isUint64 {
+		n, err := io.WriteString(w, uint64Header)
+		size += int64(n)
+		if err != nil {
+			return size, err
+		}
+		for i, v := range b.units64 {
+			if err := binary.Write(w, binary.LittleEndian, uint64(v)); err != nil {
+				return size, err
+			}
+			size += unit64Size
+			if (i+1)%blockSize == 0 || i+1 == len(b.units64) {
+				b.incrementProgress(1)
+			}
+		}
+		b.finishPhase(PhaseWrite)
+		return size, nil
+	}
+	for i, v := range b.units {
 		if err := binary.Write(w, binary.LittleEndian, uint32(v)); err != nil {
 			return size, err
 		}
 		size += 4
+		if (i+1)%blockSize == 0 || i+1 == len(b.units) {
+			b.incrementProgress(1)
+		}
 	}
+	b.finishPhase(PhaseWrite)
 	return size, nil
 }
 
+// WriteDartsCloneTo writes the double array in the format of Darts::DoubleArray::save of the C++ darts-clone,
+// which is the units of the 32-bit layout in the little-endian without any header.
+// The double array of the 64-bit layout cannot be written in the format.
+func (b DoubleArrayBuilder) WriteDartsCloneTo(w io.Writer) (int64, error) {
+	if b.isUint64 {
+		return 0, fmt.Errorf("the double array of the 64-bit layout is not compatible with darts-clone")
+	}
+	return b.WriteTo(w)
+}
+
+func (b DoubleArrayBuilder) numUnits() int {
+	if b.isUint64 {
+		return len(b.units64)
+	}
+	return len(b.units)
+}
+
 func (b DoubleArrayBuilder) numBlocks() int {
-	return len(b.units) / blockSize
+	return b.numUnits() / blockSize
+}
+
+func (b *DoubleArrayBuilder) setHasLeaf(id int, hasLeaf bool) {
+	if b.isUint64 {
+		b.units64[id].setHasLeaf(hasLeaf)
+		return
+	}
+	b.units[id].setHasLeaf(hasLeaf)
+}
+
+func (b *DoubleArrayBuilder) setValue(id int, value uint32) {
+	if b.isUint64 {
+		b.units64[id].setValue(value)
+		return
+	}
+	b.units[id].setValue(value)
+}
+
+func (b *DoubleArrayBuilder) setLabel(id int, label byte) {
+	if b.isUint64 {
+		b.units64[id].setLabel(label)
+		return
+	}
+	b.units[id].setLabel(label)
+}
+
+func (b *DoubleArrayBuilder) setOffset(id int, offset int) error {
+	if b.isUint64 {
+		return b.units64[id].setOffset(uint64(offset))
+	}
+	if offset >= maxOffset {
+		b.overflow = true
+	}
+	return b.units[id].setOffset(uint32(offset))
 }
 
 func (b DoubleArrayBuilder) getExtras(id int) *extraUnit {
 	return &b.extras[id%numExtras]
 }
 
-func (b DoubleArrayBuilder) buildDAWG(keySet *keySet) (*dawg.Graph, error) {
+func (b *DoubleArrayBuilder) buildDAWG(keySet *keySet) (*dawg.Graph, error) {
 	dawgBuilder := dawg.NewBuilder()
 	for i := 0; i < keySet.size(); i++ {
+		if i%contextCheckInterval == 0 {
+			if err := b.contextErr(); err != nil {
+				return nil, err
+			}
+		}
 		k, err := keySet.getKey(i)
 		if err != nil {
 			return nil, fmt.Errorf("key set get key, %v", err)
@@ -136,8 +533,122 @@ func (b DoubleArrayBuilder) buildDAWG(keySet *keySet) (*dawg.Graph, error) {
 		}
 
 		// progress bar
-		if b.progress != nil {
-			b.progress.Increment()
+		b.incrementProgress(1)
+	}
+	g, err := dawgBuilder.Finish()
+	if err != nil {
+		return nil, fmt.Errorf("DAWG builder finish, %v", err)
+	}
+	return g, nil
+}
+
+func (b *DoubleArrayBuilder) buildParallel(keySet *keySet, parallelism int) error {
+	b.startPhase(PhaseArrange, keySet.size())
+	parts, err := keySet.partition()
+	if err != nil {
+		return fmt.Errorf("partition key set, %v", err)
+	}
+	type result struct {
+		g       *dawg.Graph
+		elapsed time.Duration
+		err     error
+	}
+	ctx := b.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	results := make([]chan result, len(parts))
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+	jobs := make(chan int)
+	quit := make(chan struct{})
+	defer close(quit)
+	go func() {
+		defer close(jobs)
+		for i := range parts {
+			select {
+			case jobs <- i:
+			case <-quit:
+				return
+			}
+		}
+	}()
+	for i := 0; i < parallelism; i++ {
+		go func() {
+			for id := range jobs {
+				start := time.Now()
+				g, err := buildPartialDAWG(ctx, keySet, parts[id].begin, parts[id].end)
+				results[id] <- result{g: g, elapsed: time.Since(start), err: err}
+			}
+		}()
+	}
+
+	start := time.Now()
+	var wait time.Duration
+	labels := make([]byte, 0, len(parts))
+	for _, p := range parts {
+		labels = append(labels, p.label)
+	}
+	offset, err := b.buildFromDAWGsRoot(labels)
+	if err != nil {
+		return fmt.Errorf("arrange root, %v", err)
+	}
+	for i, p := range parts {
+		var r result
+		waitStart := time.Now()
+		select {
+		case r = <-results[i]:
+		case <-ctx.Done():
+			return b.contextErr()
+		}
+		wait += time.Since(waitStart)
+		if r.err != nil && r.err == ctx.Err() {
+			return b.contextErr()
+		} else if r.err != nil {
+			return fmt.Errorf("build DAWG, %v", r.err)
+		}
+		b.stats.DAWGTime += r.elapsed
+		b.stats.NumDAWGNodes += r.g.Size()
+		b.stats.NumIntersections += r.g.NumIntersections()
+		b.table = make([]int, r.g.NumIntersections())
+		child, err := r.g.Child(r.g.Root())
+		if err != nil {
+			return fmt.Errorf("invalid root child, %v", err)
+		}
+		if err := b.buildFromDAWGInsert(r.g, child, offset^int(p.label)); err != nil {
+			return fmt.Errorf("insert from DAWG, %v", err)
+		}
+		// progress bar
+		b.incrementProgress(p.end - p.begin)
+	}
+	b.finishPhase(PhaseArrange)
+	b.fixAllBlocks()
+	b.extras = nil
+	b.labels = nil
+	b.table = nil
+	b.stats.ArrangeTime = time.Since(start) - wait - b.stats.FixBlocksTime
+	return nil
+}
+
+func buildPartialDAWG(ctx context.Context, keySet *keySet, begin, end int) (*dawg.Graph, error) {
+	dawgBuilder := dawg.NewBuilder()
+	for i := begin; i < end; i++ {
+		if (i-begin)%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		k, err := keySet.getKey(i)
+		if err != nil {
+			return nil, fmt.Errorf("key set get key, %v", err)
+		}
+		v, err := keySet.getValue(i)
+		if err != nil {
+			return nil, fmt.Errorf("key set get value, %v", err)
+		}
+		if err := dawgBuilder.Insert(k, v); err != nil {
+			return nil, fmt.Errorf("DAWG builder insert, %v", err)
 		}
 	}
 	g, err := dawgBuilder.Finish()
@@ -147,6 +658,35 @@ func (b DoubleArrayBuilder) buildDAWG(keySet *keySet) (*dawg.Graph, error) {
 	return g, nil
 }
 
+// buildFromDAWGsRoot arranges the root and its children which have the labels,
+// and returns the offset of the children.
+func (b *DoubleArrayBuilder) buildFromDAWGsRoot(labels []byte) (int, error) {
+	b.extras = make([]extraUnit, numExtras)
+
+	b.reserveID(0)
+	b.extras[0].isUsed = true
+	if err := b.setOffset(0, 1); err != nil {
+		return -1, fmt.Errorf("set offset, %v", err)
+	}
+	b.setLabel(0, 0)
+	if len(labels) == 0 {
+		return 0, nil
+	}
+
+	b.labels = labels
+	offset := b.findValidOffset(0)
+	if err := b.setOffset(0, offset); err != nil {
+		return -1, fmt.Errorf("set offset, %v", err)
+	}
+	for _, l := range labels {
+		dicChildID := offset ^ int(l)
+		b.reserveID(dicChildID)
+		b.setLabel(dicChildID, l)
+	}
+	b.getExtras(offset).isUsed = true
+	return offset, nil
+}
+
 func (b *DoubleArrayBuilder) buildFromDAWGHeader(g *dawg.Graph) error {
 	numUnits := 1
 	for numUnits < g.Size() {
@@ -157,10 +697,10 @@ func (b *DoubleArrayBuilder) buildFromDAWGHeader(g *dawg.Graph) error {
 
 	b.reserveID(0)
 	b.extras[0].isUsed = true
-	if err := b.units[0].setOffset(1); err != nil {
+	if err := b.setOffset(0, 1); err != nil {
 		return fmt.Errorf("set offset, %v", err)
 	}
-	b.units[0].setLabel(0)
+	b.setLabel(0, 0)
 
 	if id, err := g.Child(g.Root()); err != nil {
 		return fmt.Errorf("invalid root child, %v", err)
@@ -169,6 +709,7 @@ func (b *DoubleArrayBuilder) buildFromDAWGHeader(g *dawg.Graph) error {
 			return fmt.Errorf("insert from DAWG, %v", err)
 		}
 	}
+	b.finishPhase(PhaseArrange)
 	b.fixAllBlocks()
 	b.extras = nil
 	b.labels = nil
@@ -177,7 +718,12 @@ func (b *DoubleArrayBuilder) buildFromDAWGHeader(g *dawg.Graph) error {
 }
 
 func (b *DoubleArrayBuilder) buildFromDAWGInsert(g *dawg.Graph, dawgID uint32, dicID int) error {
+	if err := b.checkContext(); err != nil {
+		return err
+	}
 	dawgChildID, _ := g.Child(dawgID)
+	// arranged is true if the children are the intersection arranged already, they are counted once in the progress.
+	var arranged bool
 	if ok, err := g.IsIntersection(dawgChildID); err != nil {
 		return fmt.Errorf("invalid intersection, %v", err)
 	} else if ok {
@@ -186,6 +732,7 @@ func (b *DoubleArrayBuilder) buildFromDAWGInsert(g *dawg.Graph, dawgID uint32, d
 			return fmt.Errorf("invalid intersection ID, %v", err)
 		}
 		offset := b.table[intersectionID]
+		arranged = offset != 0
 		if offset != 0 {
 			offset ^= dicID
 			if (offset&upperMask) == 0 || (offset&lowerMask) == 0 {
@@ -194,9 +741,9 @@ func (b *DoubleArrayBuilder) buildFromDAWGInsert(g *dawg.Graph, dawgID uint32, d
 					return fmt.Errorf("invalid leaf, %v", err)
 				}
 				if ok {
-					b.units[dicID].setHasLeaf(true)
+					b.setHasLeaf(dicID, true)
 				}
-				if err := b.units[dicID].setOffset(uint32(offset)); err != nil {
+				if err := b.setOffset(dicID, offset); err != nil {
 					return fmt.Errorf("set offset, %v", err)
 				}
 				return nil
@@ -208,6 +755,10 @@ func (b *DoubleArrayBuilder) buildFromDAWGInsert(g *dawg.Graph, dawgID uint32, d
 	if err != nil {
 		return fmt.Errorf("arrange from DAWG, %v", err)
 	}
+	// progress bar
+	if b.progressNodes && !arranged {
+		b.incrementProgress(len(b.labels))
+	}
 	if ok, err := g.IsIntersection(dawgChildID); err != nil {
 		return fmt.Errorf("invalid intersection, %v", err)
 	} else if ok {
@@ -239,7 +790,7 @@ func (b *DoubleArrayBuilder) buildFromDAWGInsert(g *dawg.Graph, dawgID uint32, d
 }
 
 func (b *DoubleArrayBuilder) arrangeFromDAWG(g *dawg.Graph, dawgID uint32, dicID int) (int, error) {
-	if dicID < 0 || dicID >= len(b.units) {
+	if dicID < 0 || dicID >= b.numUnits() {
 		return -1, fmt.Errorf("dicID, index out of bounds, %v", dicID)
 	}
 	b.labels = []byte{}
@@ -257,10 +808,9 @@ func (b *DoubleArrayBuilder) arrangeFromDAWG(g *dawg.Graph, dawgID uint32, dicID
 		}
 	}
 	offset := b.findValidOffset(dicID)
-	if err := b.units[dicID].setOffset(uint32(dicID ^ offset)); err != nil {
+	if err := b.setOffset(dicID, dicID^offset); err != nil {
 		return -1, fmt.Errorf("set offset, %v", err)
 	}
-
 	var err error
 	dawgChildID, err = g.Child(dawgID)
 	if err != nil {
@@ -272,14 +822,14 @@ func (b *DoubleArrayBuilder) arrangeFromDAWG(g *dawg.Graph, dawgID uint32, dicID
 		if ok, err := g.IsLeaf(dawgChildID); err != nil {
 			return -1, fmt.Errorf("invalid leaf, %v", err)
 		} else if !ok {
-			b.units[dicChildID].setLabel(l)
+			b.setLabel(dicChildID, l)
 		} else {
-			b.units[dicID].setHasLeaf(true)
+			b.setHasLeaf(dicID, true)
 			v, err := g.Value(dawgChildID)
 			if err != nil {
 				return -1, fmt.Errorf("invalid value, %v", err)
 			}
-			b.units[dicChildID].setValue(v)
+			b.setValue(dicChildID, v)
 		}
 		dawgChildID, err = g.Sibling(dawgChildID)
 		if err != nil {
@@ -299,14 +849,17 @@ func (b *DoubleArrayBuilder) buildFromKeySetHeader(keySet *keySet) error {
 
 	b.reserveID(0)
 	b.extras[0].isUsed = true
-	if err := b.units[0].setOffset(1); err != nil {
+	if err := b.setOffset(0, 1); err != nil {
 		return fmt.Errorf("set offset, %v", err)
 	}
-	b.units[0].setLabel(0)
+	b.setLabel(0, 0)
 
 	if keySet.size() > 0 {
-		b.buildFromKeySetInsert(keySet, 0, keySet.size(), 0, 0)
+		if err := b.buildFromKeySetInsert(keySet, 0, keySet.size(), 0, 0); err != nil {
+			return fmt.Errorf("insert from key set, %v", err)
+		}
 	}
+	b.finishPhase(PhaseArrange)
 
 	b.fixAllBlocks()
 
@@ -317,6 +870,9 @@ func (b *DoubleArrayBuilder) buildFromKeySetHeader(keySet *keySet) error {
 }
 
 func (b *DoubleArrayBuilder) buildFromKeySetInsert(keySet *keySet, begin, end, depth, dicID int) error {
+	if err := b.checkContext(); err != nil {
+		return err
+	}
 	offset, err := b.arrangeFromKeySet(keySet, begin, end, depth, dicID)
 	if err != nil {
 		return fmt.Errorf("arrange from key set, %v", err)
@@ -347,7 +903,9 @@ func (b *DoubleArrayBuilder) buildFromKeySetInsert(keySet *keySet, begin, end, d
 			return fmt.Errorf("get key byte, %v", err)
 		}
 		if label != lastLabel {
-			b.buildFromKeySetInsert(keySet, lastBegin, begin, depth+1, offset^int(lastLabel))
+			if err := b.buildFromKeySetInsert(keySet, lastBegin, begin, depth+1, offset^int(lastLabel)); err != nil {
+				return err
+			}
 			lastBegin = begin
 			lastLabel, err = keySet.getKeyByte(begin, depth)
 			if err != nil {
@@ -355,8 +913,7 @@ func (b *DoubleArrayBuilder) buildFromKeySetInsert(keySet *keySet, begin, end, d
 			}
 		}
 	}
-	b.buildFromKeySetInsert(keySet, lastBegin, end, depth+1, int(uint32(offset)^uint32(lastLabel)))
-	return nil
+	return b.buildFromKeySetInsert(keySet, lastBegin, end, depth+1, offset^int(lastLabel))
 }
 
 func (b *DoubleArrayBuilder) arrangeFromKeySet(keySet *keySet, begin, end, depth, dicID int) (int, error) {
@@ -383,9 +940,7 @@ func (b *DoubleArrayBuilder) arrangeFromKeySet(keySet *keySet, begin, end, depth
 				value = int(val)
 			}
 			// progress bar
-			if b.progress != nil {
-				b.progress.Increment()
-			}
+			b.incrementProgress(1)
 		}
 		if len(b.labels) == 0 {
 			b.labels = append(b.labels, label)
@@ -398,10 +953,10 @@ func (b *DoubleArrayBuilder) arrangeFromKeySet(keySet *keySet, begin, end, depth
 	}
 
 	offset := b.findValidOffset(dicID)
-	if dicID < 0 || dicID >= len(b.units) {
+	if dicID < 0 || dicID >= b.numUnits() {
 		return -1, fmt.Errorf("dicID, index out of bounds, %v", dicID)
 	}
-	if err := b.units[dicID].setOffset(uint32(dicID ^ offset)); err != nil {
+	if err := b.setOffset(dicID, dicID^offset); err != nil {
 		return -1, fmt.Errorf("set offset, %v", err)
 	}
 
@@ -409,10 +964,10 @@ func (b *DoubleArrayBuilder) arrangeFromKeySet(keySet *keySet, begin, end, depth
 		dicChildID := offset ^ int(l)
 		b.reserveID(dicChildID)
 		if l != 0 {
-			b.units[dicChildID].setLabel(l)
+			b.setLabel(dicChildID, l)
 		} else {
-			b.units[dicID].setHasLeaf(true)
-			b.units[dicChildID].setValue(uint32(value))
+			b.setHasLeaf(dicID, true)
+			b.setValue(dicChildID, uint32(value))
 		}
 	}
 	b.getExtras(offset).isUsed = true
@@ -421,8 +976,8 @@ func (b *DoubleArrayBuilder) arrangeFromKeySet(keySet *keySet, begin, end, depth
 }
 
 func (b DoubleArrayBuilder) findValidOffset(id int) int {
-	if b.extrasHead >= len(b.units) {
-		return len(b.units) | (id & lowerMask)
+	if b.extrasHead >= b.numUnits() {
+		return b.numUnits() | (id & lowerMask)
 	}
 	unfixedID := b.extrasHead
 	memo := map[int]struct{}{}
@@ -441,7 +996,7 @@ func (b DoubleArrayBuilder) findValidOffset(id int) int {
 			break
 		}
 	}
-	return len(b.units) | (id & lowerMask)
+	return b.numUnits() | (id & lowerMask)
 }
 
 func (b DoubleArrayBuilder) isValidOffset(id, offset int) bool {
@@ -461,13 +1016,13 @@ func (b DoubleArrayBuilder) isValidOffset(id, offset int) bool {
 }
 
 func (b *DoubleArrayBuilder) reserveID(id int) {
-	if id >= len(b.units) {
+	if id >= b.numUnits() {
 		b.expandUnits()
 	}
 	if id == b.extrasHead {
 		b.extrasHead = b.getExtras(id).next
 		if b.extrasHead == id {
-			b.extrasHead = len(b.units)
+			b.extrasHead = b.numUnits()
 		}
 	}
 	b.getExtras(b.getExtras(id).prev).next = b.getExtras(id).next
@@ -476,7 +1031,7 @@ func (b *DoubleArrayBuilder) reserveID(id int) {
 }
 
 func (b *DoubleArrayBuilder) expandUnits() {
-	srcNumUnits := len(b.units)
+	srcNumUnits := b.numUnits()
 	srcNumBlocks := b.numBlocks()
 
 	destNumUnits := srcNumUnits + blockSize
@@ -486,6 +1041,10 @@ func (b *DoubleArrayBuilder) expandUnits() {
 		b.fixBlock(srcNumBlocks - numExtraBlocks)
 	}
 	for i := srcNumUnits; i < destNumUnits; i++ {
+		if b.isUint64 {
+			b.units64 = append(b.units64, unit64(0))
+			continue
+		}
 		b.units = append(b.units, unit(0))
 	}
 	if destNumBlocks > numExtraBlocks {
@@ -516,12 +1075,19 @@ func (b *DoubleArrayBuilder) fixAllBlocks() {
 	}
 	end := b.numBlocks()
 
+	b.startPhase(PhaseFixBlocks, end-begin)
 	for blockID := begin; blockID < end; blockID++ {
 		b.fixBlock(blockID)
+		b.incrementProgress(1)
 	}
+	b.finishPhase(PhaseFixBlocks)
 }
 
 func (b *DoubleArrayBuilder) fixBlock(blockID int) {
+	start := time.Now()
+	defer func() {
+		b.stats.FixBlocksTime += time.Since(start)
+	}()
 	begin := blockID * blockSize
 	end := begin + blockSize
 
@@ -535,7 +1101,8 @@ func (b *DoubleArrayBuilder) fixBlock(blockID int) {
 	for id := begin; id < end; id++ {
 		if !b.getExtras(id).isFixed {
 			b.reserveID(id)
-			b.units[id].setLabel(byte(id ^ unusedOffset))
+			b.setLabel(id, byte(id^unusedOffset))
+			b.stats.NumFillerUnits++
 		}
 	}
 }
@@ -17,10 +17,13 @@ package internal
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"io/ioutil"
 	"os"
 	"reflect"
+	"sort"
 	"testing"
+	"time"
 )
 
 func TestDoubleArrayBuilder_Build(t *testing.T) {
@@ -122,6 +125,310 @@ func TestDoubleArrayBuilder_Build(t *testing.T) {
 	})
 }
 
+func TestDoubleArrayBuilder_BuildParallel(t *testing.T) {
+	f, err := os.Open("./_testdata/keys.txt")
+	if err != nil {
+		t.Fatalf("unexpected open file error, %v", err)
+	}
+	defer f.Close()
+	var (
+		keys   []string
+		values []uint32
+	)
+	scanner := bufio.NewScanner(f)
+	for i := 0; scanner.Scan(); i++ {
+		keys = append(keys, scanner.Text())
+		values = append(values, uint32(i*3))
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("unexpected scanner error, %v", err)
+	}
+	serial, err := BuildDoubleArray(append([]string{}, keys...), append([]uint32{}, values...), nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	for _, parallelism := range []int{0, 1, 4} {
+		t.Run("w/ values", func(t *testing.T) {
+			b := NewDoubleArrayBuilder(nil)
+			if err := b.BuildParallel(append([]string{}, keys...), append([]uint32{}, values...), parallelism); err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			da, err := b.DoubleArrayUint32()
+			if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			for _, key := range keys {
+				expected, err := serial.CommonPrefixSearch(key, 0)
+				if err != nil {
+					t.Fatalf("unexpected error, %v", err)
+				}
+				got, err := da.CommonPrefixSearch(key, 0)
+				if err != nil {
+					t.Fatalf("unexpected error, %v", err)
+				}
+				if !reflect.DeepEqual(expected, got) {
+					t.Fatalf("parallelism=%v, key=%v, expected %v, got %v", parallelism, key, expected, got)
+				}
+			}
+		})
+		t.Run("w/o values", func(t *testing.T) {
+			b := NewDoubleArrayBuilder(nil)
+			sorted := append([]string{}, keys...)
+			if err := b.BuildParallel(sorted, nil, parallelism); err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			da, err := b.DoubleArrayUint32()
+			if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			for i, key := range sorted {
+				if id, size, err := da.ExactMatchSearch(key); err != nil {
+					t.Errorf("unexpected error, %v", err)
+				} else if id != i {
+					t.Errorf("unexpected id, expected %v, got %v", i, id)
+				} else if size != len(key) {
+					t.Errorf("unexpected size, expected %v, got %v", len(key), size)
+				}
+			}
+			if id, _, err := da.ExactMatchSearch("\xff\xff"); err != nil {
+				t.Errorf("unexpected error, %v", err)
+			} else if id != -1 {
+				t.Errorf("unexpected id, expected -1, got %v", id)
+			}
+		})
+	}
+	t.Run("empty", func(t *testing.T) {
+		b := NewDoubleArrayBuilder(nil)
+		if err := b.BuildParallel(nil, nil, 2); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		da, err := b.DoubleArrayUint32()
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if id, _, err := da.ExactMatchSearch("a"); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		} else if id != -1 {
+			t.Errorf("unexpected id, expected -1, got %v", id)
+		}
+	})
+	t.Run("invalid key", func(t *testing.T) {
+		b := NewDoubleArrayBuilder(nil)
+		if err := b.BuildParallel([]string{"a", "b\x00c", "c"}, []uint32{1, 2, 3}, 2); err == nil {
+			t.Errorf("expected invalid null character error")
+		}
+	})
+}
+
+func TestDoubleArrayBuilder_BuildContext(t *testing.T) {
+	f, err := os.Open("./_testdata/keys.txt")
+	if err != nil {
+		t.Fatalf("unexpected open file error, %v", err)
+	}
+	defer f.Close()
+	var (
+		keys   []string
+		values []uint32
+	)
+	scanner := bufio.NewScanner(f)
+	for i := 0; scanner.Scan(); i++ {
+		keys = append(keys, scanner.Text())
+		values = append(values, uint32(i))
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("unexpected scanner error, %v", err)
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	t.Run("canceled w/ values", func(t *testing.T) {
+		b := NewDoubleArrayBuilder(nil)
+		if err := b.BuildContext(canceled, keys, values); err != context.Canceled {
+			t.Errorf("expected %v, got %v", context.Canceled, err)
+		}
+	})
+	t.Run("canceled w/o values", func(t *testing.T) {
+		b := NewDoubleArrayBuilder(nil)
+		if err := b.BuildContext(canceled, keys, nil); err != context.Canceled {
+			t.Errorf("expected %v, got %v", context.Canceled, err)
+		}
+	})
+	t.Run("canceled parallel", func(t *testing.T) {
+		b := NewDoubleArrayBuilder(nil)
+		if err := b.BuildParallelContext(canceled, keys, values, 2); err != context.Canceled {
+			t.Errorf("expected %v, got %v", context.Canceled, err)
+		}
+	})
+	t.Run("invalid keys after cancel", func(t *testing.T) {
+		b := NewDoubleArrayBuilder(nil)
+		err := b.BuildParallelContext(canceled, []string{"", "a"}, nil, 2)
+		if err == nil || err == context.Canceled {
+			t.Errorf("expected error of the zero-length key, got %v", err)
+		}
+	})
+	t.Run("deadline exceeded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		b := NewDoubleArrayBuilder(nil)
+		if err := b.BuildContext(ctx, keys, nil); err != context.DeadlineExceeded {
+			t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+		}
+	})
+	t.Run("not canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		b := NewDoubleArrayBuilder(nil)
+		if err := b.BuildContext(ctx, []string{"aaa", "bbb"}, []uint32{7, 5}); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+	})
+}
+
+// sliceKeySource is the key source of the keys and the values in the slices.
+type sliceKeySource struct {
+	keys   []string
+	values []uint32
+	pos    int
+}
+
+func (s *sliceKeySource) Next() bool {
+	if s.pos >= len(s.keys) {
+		return false
+	}
+	s.pos++
+	return true
+}
+
+func (s sliceKeySource) Key() string   { return s.keys[s.pos-1] }
+func (s sliceKeySource) Value() uint32 { return s.values[s.pos-1] }
+func (s sliceKeySource) Err() error    { return nil }
+
+func TestDoubleArrayBuilder_BuildStream(t *testing.T) {
+	f, err := os.Open("./_testdata/keys.txt")
+	if err != nil {
+		t.Fatalf("unexpected open file error, %v", err)
+	}
+	defer f.Close()
+	var keys []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		keys = append(keys, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("unexpected scanner error, %v", err)
+	}
+	sort.Strings(keys)
+	values := make([]uint32, len(keys))
+	for i := range values {
+		values[i] = uint32(i * 3)
+	}
+
+	t.Run("same as build", func(t *testing.T) {
+		expected := NewDoubleArrayBuilder(nil)
+		if err := expected.Build(append([]string{}, keys...), append([]uint32{}, values...)); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		b := NewDoubleArrayBuilder(nil)
+		if err := b.BuildStream(&sliceKeySource{keys: keys, values: values}); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if !reflect.DeepEqual(expected.toArray(), b.toArray()) {
+			t.Errorf("expected the same units as Build")
+		}
+		if got, want := b.Stats().NumKeys, len(keys); got != want {
+			t.Errorf("num keys: expected %v, got %v", want, got)
+		}
+		if got, want := b.Stats().KeyBytes, expected.Stats().KeyBytes; got != want {
+			t.Errorf("key bytes: expected %v, got %v", want, got)
+		}
+	})
+	t.Run("too large values select 64-bit layout", func(t *testing.T) {
+		b := NewDoubleArrayBuilder(nil)
+		if err := b.BuildStream(&sliceKeySource{keys: []string{"aaa", "bbb"}, values: []uint32{7, 1 << 31}}); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		da, err := b.DoubleArrayUint64()
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if id, _, err := da.ExactMatchSearch("bbb"); err != nil || uint32(id) != 1<<31 {
+			t.Errorf("expected id=%v, got id=%v, err=%v", uint32(1<<31), id, err)
+		}
+	})
+	t.Run("invalid keys", func(t *testing.T) {
+		for _, tt := range [][]string{{"b", "a"}, {"a", "a"}, {"", "a"}, {"a", "b\x00c"}} {
+			b := NewDoubleArrayBuilder(nil)
+			if err := b.BuildStream(&sliceKeySource{keys: tt, values: make([]uint32, len(tt))}); err == nil {
+				t.Errorf("expected error of the keys %q", tt)
+			}
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		b := NewDoubleArrayBuilder(nil)
+		if err := b.BuildStreamContext(ctx, &sliceKeySource{keys: keys, values: values}); err != context.Canceled {
+			t.Errorf("expected %v, got %v", context.Canceled, err)
+		}
+	})
+}
+
+func TestDoubleArrayBuilder_Build_Uint64(t *testing.T) {
+	t.Run("32-bit layout", func(t *testing.T) {
+		b := NewDoubleArrayBuilder(nil)
+		if err := b.Build([]string{"aaa", "bbb"}, []uint32{7, 1<<31 - 1}); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if b.IsUint64() {
+			t.Errorf("expected the 32-bit layout")
+		}
+		if _, err := b.DoubleArrayUint64(); err == nil {
+			t.Errorf("expected layout error")
+		}
+	})
+	t.Run("too large values select 64-bit layout", func(t *testing.T) {
+		b := NewDoubleArrayBuilder(nil)
+		keys := []string{"aaa", "bbb"}
+		values := []uint32{7, 1 << 31}
+		if err := b.Build(keys, values); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if !b.IsUint64() {
+			t.Fatalf("expected the 64-bit layout")
+		}
+		if _, err := b.DoubleArrayUint32(); err == nil {
+			t.Errorf("expected layout error")
+		}
+		da, err := b.DoubleArrayUint64()
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		for i, key := range keys {
+			if id, size, err := da.ExactMatchSearch(key); err != nil {
+				t.Errorf("unexpected error, %v", err)
+			} else if id != int(values[i]) {
+				t.Errorf("unexpected id, expected %v, got %v", values[i], id)
+			} else if size != len(key) {
+				t.Errorf("unexpected size, expected %v, got %v", len(key), size)
+			}
+		}
+	})
+	t.Run("overflow of offsets retries with 64-bit layout", func(t *testing.T) {
+		b := NewDoubleArrayBuilder(nil)
+		b.units = []unit{0}
+		if err := b.setOffset(0, maxOffset); err == nil {
+			t.Fatalf("expected too large offset error")
+		}
+		if !b.overflow {
+			t.Errorf("expected overflow")
+		}
+		b.reset()
+		if b.overflow || b.units != nil {
+			t.Errorf("expected reset builder")
+		}
+	})
+}
+
 func TestDoubleArrayBuilder_WriteTo(t *testing.T) {
 	t.Run("small test", func(t *testing.T) {
 
@@ -146,6 +453,27 @@ func TestDoubleArrayBuilder_WriteTo(t *testing.T) {
 			t.Errorf("expected %v, got %v", expected, got)
 		}
 	})
+	t.Run("64-bit layout", func(t *testing.T) {
+		builder := DoubleArrayBuilder{
+			units64:  []unit64{1, 2},
+			isUint64: true,
+		}
+		var b bytes.Buffer
+		if size, err := builder.WriteTo(&b); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		} else if expected := int64(len(uint64Header) + unit64Size*2); size != expected {
+			t.Errorf("expected %v, got %v", expected, size)
+		}
+		got := b.Bytes()
+		expected := []byte{
+			'D', 'A', 'R', 'T', 'S', '6', '4', 0, // header
+			1, 0, 0, 0, 0, 0, 0, 0, // uint64(1)
+			2, 0, 0, 0, 0, 0, 0, 0, // uint64(2)
+		}
+		if !reflect.DeepEqual(expected, got) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
 	t.Run("build from keys.txt and write to file", func(t *testing.T) {
 		f, err := os.Open("./_testdata/keys.txt")
 		if err != nil {
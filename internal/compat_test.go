@@ -0,0 +1,191 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const dartsCloneTestdata = "./_testdata/darts-clone"
+
+// readDartsCloneKeys reads the key file of the golden file, a key or a key and a value separated by a tab per line.
+func readDartsCloneKeys(t *testing.T, name string) ([]string, []uint32) {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer f.Close()
+	var keys []string
+	var values []uint32
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := s.Text()
+		if line == "" {
+			continue
+		}
+		if i := strings.LastIndexByte(line, '\t'); i >= 0 {
+			v, err := strconv.ParseUint(line[i+1:], 10, 31)
+			if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			values = append(values, uint32(v))
+			line = line[:i]
+		}
+		keys = append(keys, line)
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	return keys, values
+}
+
+func TestDartsCloneCompatibility(t *testing.T) {
+	for _, input := range []string{"no_values", "values"} {
+		t.Run(input, func(t *testing.T) {
+			keys, values := readDartsCloneKeys(t, filepath.Join(dartsCloneTestdata, input+".txt"))
+			golden := filepath.Join(dartsCloneTestdata, input+".darts")
+			want, err := ioutil.ReadFile(golden)
+			if os.IsNotExist(err) {
+				t.Skipf("golden file %s not found, generate it by generate.cc with the C++ darts-clone", golden)
+			} else if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+
+			b := NewDoubleArrayBuilder(nil)
+			if err := b.Build(keys, values); err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			var buf bytes.Buffer
+			if _, err := b.WriteDartsCloneTo(&buf); err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("written data differs from the golden file, got %d bytes, want %d bytes", buf.Len(), len(want))
+			}
+
+			a, err := Open(golden)
+			if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			if err := a.Validate(); err != nil {
+				t.Errorf("unexpected error, %v", err)
+			}
+			for i, k := range keys {
+				id, size, err := a.ExactMatchSearch(k)
+				if err != nil {
+					t.Fatalf("unexpected error, %v", err)
+				}
+				want := i
+				if len(values) > 0 {
+					want = int(values[i])
+				}
+				if id != want || size != len(k) {
+					t.Errorf("key %q, got (%d, %d), want (%d, %d)", k, id, size, want, len(k))
+				}
+			}
+		})
+	}
+}
+
+func TestDartsCloneLayout(t *testing.T) {
+	keys, values := readDartsCloneKeys(t, filepath.Join(dartsCloneTestdata, "values.txt"))
+	b := NewDoubleArrayBuilder(nil)
+	if err := b.Build(keys, values); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	var buf bytes.Buffer
+	n, err := b.WriteDartsCloneTo(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("got %d, written %d bytes", n, buf.Len())
+	}
+	if n%(unitSize*blockSize) != 0 {
+		t.Errorf("got %d bytes, not a multiple of the block", n)
+	}
+	data := buf.Bytes()
+	if bytes.HasPrefix(data, []byte(uint64Header)) {
+		t.Errorf("unexpected header")
+	}
+	if root := binary.LittleEndian.Uint32(data); root&(1<<31|0xFF) != 0 {
+		t.Errorf("got root unit 0x%08X, want label 0", root)
+	}
+	for i, k := range keys {
+		if id, ok := lookupDartsClone(data, k); !ok || id != int(values[i]) {
+			t.Errorf("key %q, got (%d, %v), want (%d, true)", k, id, ok, values[i])
+		}
+	}
+	for _, k := range []string{"", "x", keys[0] + "\x00"} {
+		if id, ok := lookupDartsClone(data, k); ok {
+			t.Errorf("key %q, got %d, want not found", k, id)
+		}
+	}
+
+	b64 := NewDoubleArrayBuilder(nil)
+	if err := b64.Build([]string{"a"}, []uint32{1 << 31}); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if !b64.IsUint64() {
+		t.Fatalf("expected the 64-bit layout")
+	}
+	if _, err := b64.WriteDartsCloneTo(&buf); err == nil {
+		t.Errorf("expected error of the 64-bit layout")
+	}
+}
+
+// lookupDartsClone searches the key in the units of the darts-clone file by the bits of the layout,
+// independently of unit, and returns the value if found.
+func lookupDartsClone(data []byte, key string) (int, bool) {
+	at := func(i uint32) (uint32, bool) {
+		if uint64(i)*4+4 > uint64(len(data)) {
+			return 0, false
+		}
+		return binary.LittleEndian.Uint32(data[i*4:]), true
+	}
+	offset := func(u uint32) uint32 {
+		if u&(1<<9) != 0 {
+			return (u >> 10) << 8
+		}
+		return u >> 10
+	}
+	pos := uint32(0)
+	u, ok := at(pos)
+	if !ok {
+		return -1, false
+	}
+	for i := 0; i < len(key); i++ {
+		pos ^= offset(u) ^ uint32(key[i])
+		if u, ok = at(pos); !ok || u&(1<<31|0xFF) != uint32(key[i]) {
+			return -1, false
+		}
+	}
+	if u&(1<<8) == 0 {
+		return -1, false
+	}
+	if u, ok = at(pos ^ offset(u)); !ok || u&(1<<31) == 0 {
+		return -1, false
+	}
+	return int(u &^ (1 << 31)), true
+}
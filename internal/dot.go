@@ -0,0 +1,134 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// writeDOT writes the units reachable from the node of the prefix in the Graphviz DOT language.
+// The nodes are the units named by the indexes, the leaf units are the boxes with the values.
+func writeDOT(w io.Writer, at unitAccessor, prefix string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph doublearray {")
+	fmt.Fprintln(bw, "\tnode [shape=circle];")
+	root, ok, err := dotRoot(at, prefix)
+	if err != nil {
+		return err
+	}
+	if ok {
+		fmt.Fprintf(bw, "\tu%d [label=\"%d\\n%s\", style=bold];\n", root, root, dotString(prefix))
+		if err := writeDOTUnits(bw, at, root); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotRoot returns the index of the node of the prefix.
+func dotRoot(at unitAccessor, prefix string) (uint64, bool, error) {
+	pos := uint64(0)
+	u, err := at(pos)
+	if err != nil {
+		return 0, false, err
+	}
+	for i := 0; i < len(prefix); i++ {
+		pos ^= u.offset() ^ uint64(prefix[i])
+		if u, err = at(pos); err != nil {
+			return 0, false, nil
+		}
+		if u.label() != uint64(prefix[i]) {
+			return 0, false, nil
+		}
+	}
+	return pos, true, nil
+}
+
+func writeDOTUnits(w io.Writer, at unitAccessor, root uint64) error {
+	visited := map[uint64]bool{root: true}
+	queue := []uint64{root}
+	for len(queue) > 0 {
+		pos := queue[0]
+		queue = queue[1:]
+		u, err := at(pos)
+		if err != nil {
+			return err
+		}
+		offset := pos ^ u.offset()
+		if u.hasLeaf() {
+			v, err := at(offset)
+			if err != nil {
+				return fmt.Errorf("invalid leaf, %v", err)
+			}
+			fmt.Fprintf(w, "\tu%d [label=\"%d\\nid=%d\", shape=box];\n", offset, offset, v.value())
+			fmt.Fprintf(w, "\tu%d -> u%d [label=\"\\\\0\"];\n", pos, offset)
+		}
+		for label := uint64(1); label <= 0xFF; label++ {
+			child := offset ^ label
+			v, err := at(child)
+			if err != nil || v.label() != label {
+				continue
+			}
+			fmt.Fprintf(w, "\tu%d -> u%d [label=\"%s\"];\n", pos, child, dotLabel(byte(label)))
+			if visited[child] {
+				continue
+			}
+			visited[child] = true
+			fmt.Fprintf(w, "\tu%d [label=\"%d\"];\n", child, child)
+			queue = append(queue, child)
+		}
+	}
+	return nil
+}
+
+// dotLabel returns the label of the edge, the printable ASCII character or the hexadecimal byte.
+func dotLabel(b byte) string {
+	if b == '"' || b == '\\' {
+		return `\` + string(b)
+	}
+	if 0x20 <= b && b < 0x7F {
+		return string(b)
+	}
+	return fmt.Sprintf("0x%02X", b)
+}
+
+// dotString returns the quoted string in the label.
+func dotString(s string) string {
+	var ret []byte
+	for i := 0; i < len(s); i++ {
+		ret = append(ret, dotLabel(s[i])...)
+	}
+	return `\"` + string(ret) + `\"`
+}
+
+// WriteDOT writes the units reachable from the node of the prefix in the Graphviz DOT language.
+func (a DoubleArrayUint32) WriteDOT(w io.Writer, prefix string) error {
+	return writeDOT(w, a.at64, prefix)
+}
+
+// WriteDOT writes the units reachable from the node of the prefix in the Graphviz DOT language.
+func (a DoubleArrayUint64) WriteDOT(w io.Writer, prefix string) error {
+	return writeDOT(w, a.at, prefix)
+}
+
+// WriteDOT writes the units reachable from the node of the prefix in the Graphviz DOT language.
+func (d *DynamicDoubleArray) WriteDOT(w io.Writer, prefix string) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return writeDOT(w, d.at, prefix)
+}
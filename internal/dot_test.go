@@ -0,0 +1,93 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteDOT(t *testing.T) {
+	keys := []string{"a", "ab", "abc", "b\"", "電気"}
+	values := []uint32{1, 2, 3, 4, 5}
+	a32, err := BuildDoubleArray(keys, values, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	a64, err := BuildDoubleArrayUint64(keys, values, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	d := NewDynamicDoubleArray()
+	for i, key := range keys {
+		if err := d.Insert(key, values[i]); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+	}
+	testCases := []struct {
+		prefix string
+		leaves []string
+		edges  int
+	}{
+		{prefix: "", leaves: []string{"id=1", "id=2", "id=3", "id=4", "id=5"}, edges: 16},
+		{prefix: "ab", leaves: []string{"id=2", "id=3"}, edges: 3},
+		{prefix: "x", leaves: nil, edges: 0},
+	}
+	for name, a := range map[string]interface {
+		WriteDOT(w io.Writer, prefix string) error
+	}{"32-bit": a32, "64-bit": a64, "dynamic": d} {
+		for _, tc := range testCases {
+			var buf bytes.Buffer
+			if err := a.WriteDOT(&buf, tc.prefix); err != nil {
+				t.Fatalf("%v: unexpected error, %v", name, err)
+			}
+			got := buf.String()
+			if !strings.HasPrefix(got, "digraph doublearray {\n") || !strings.HasSuffix(got, "}\n") {
+				t.Errorf("%v, %q: unexpected graph, %v", name, tc.prefix, got)
+			}
+			if n := strings.Count(got, "->"); n != tc.edges {
+				t.Errorf("%v, %q: expected %v edges, got %v", name, tc.prefix, tc.edges, n)
+			}
+			if n := strings.Count(got, "shape=box"); n != len(tc.leaves) {
+				t.Errorf("%v, %q: expected %v leaves, got %v", name, tc.prefix, len(tc.leaves), n)
+			}
+			for _, leaf := range tc.leaves {
+				if !strings.Contains(got, leaf) {
+					t.Errorf("%v, %q: expected %v, got %v", name, tc.prefix, leaf, got)
+				}
+			}
+		}
+	}
+}
+
+func TestDOTLabel(t *testing.T) {
+	testCases := []struct {
+		label    byte
+		expected string
+	}{
+		{label: 'a', expected: "a"},
+		{label: '"', expected: `\"`},
+		{label: '\\', expected: `\\`},
+		{label: 0xE9, expected: "0xE9"},
+		{label: '\n', expected: "0x0A"},
+	}
+	for _, tc := range testCases {
+		if got := dotLabel(tc.label); got != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, got)
+		}
+	}
+}
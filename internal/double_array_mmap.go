@@ -0,0 +1,416 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris windows
+
+package internal
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+const (
+	MmapedFileHeaderSize = 8
+)
+
+// MmapedDoubleArray represents the TRIE data structure mapped on the virtual memory address.
+// It is safe to search concurrently with Close, the searches after Close return ErrClosed.
+type MmapedDoubleArray struct {
+	// mu guards data and raw against Close while searching.
+	mu sync.RWMutex
+	// data is the mapped memory, and raw is the double array in it.
+	data []byte
+	raw  []byte
+}
+
+// MmapedDoubleArrayUint64 represents the TRIE data structure of the 64-bit layout mapped on the virtual memory address.
+// It is safe to search concurrently with Close, the searches after Close return ErrClosed.
+type MmapedDoubleArrayUint64 struct {
+	// mu guards data and raw against Close while searching.
+	mu sync.RWMutex
+	// data is the mapped memory, and raw is the units after the header in it.
+	data []byte
+	raw  []byte
+}
+
+// OpenMmaped opens the named file of double array and maps it on the memory.
+func OpenMmaped(name string, opts ...MmapOption) (*MmapedDoubleArray, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size != int64(int(size)) {
+		return nil, fmt.Errorf("too large file")
+	}
+	return openMmap(f, 0, int(size), newMmapOptions(opts))
+}
+
+// OpenMmapedUint64 opens the named file of double array of the 64-bit layout and maps it on the memory.
+func OpenMmapedUint64(name string, opts ...MmapOption) (*MmapedDoubleArrayUint64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size != int64(int(size)) {
+		return nil, fmt.Errorf("too large file")
+	}
+	return openMmapUint64(f, 0, int(size), newMmapOptions(opts))
+}
+
+func openMmap(f *os.File, offset int64, size int, opts mmapOptions) (*MmapedDoubleArray, error) {
+	if size%unitSize != 0 {
+		return nil, fmt.Errorf("invalid file size, %v", size)
+	}
+	b, raw, err := mmap(f, offset, size)
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.apply(b); err != nil {
+		unmap(b)
+		return nil, err
+	}
+	ret := &MmapedDoubleArray{
+		data: b,
+		raw:  raw,
+	}
+	runtime.SetFinalizer(ret, (*MmapedDoubleArray).Close)
+	opts.warmupAfterOpen(ret)
+	return ret, nil
+}
+
+func (a *MmapedDoubleArray) at(i uint32) (unit, error) {
+	if int(i+1)*unitSize > len(a.raw) {
+		return 0, fmt.Errorf("index out of bounds")
+	}
+	ret := binary.LittleEndian.Uint32(a.raw[i*unitSize : (i+1)*unitSize])
+	return unit(ret), nil
+}
+
+// ExactMatchSearch searches TRIE by a given keyword and returns the id and it's length if found.
+func (a *MmapedDoubleArray) ExactMatchSearch(key string) (id, size int, err error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.raw == nil {
+		return -1, -1, ErrClosed
+	}
+	return exactMatchSearch(units[uint32]{raw: a.raw}, key)
+}
+
+// CommonPrefixSearch finds keywords sharing common prefix in an input and returns the array of pairs (id and it's length) if found.
+func (a *MmapedDoubleArray) CommonPrefixSearch(key string, offset int) ([][2]int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.raw == nil {
+		return nil, ErrClosed
+	}
+	return commonPrefixSearch(units[uint32]{raw: a.raw}, key, offset)
+}
+
+// CommonPrefixSearchCallback finds keywords sharing common prefix in an input and callback with id and it's length.
+// The callback is called after the search releases the lock against Close,
+// so it may search the double array again or close it.
+func (a *MmapedDoubleArray) CommonPrefixSearchCallback(key string, offset int, callback func(id, size int)) error {
+	ret, err := a.CommonPrefixSearch(key, offset)
+	if err != nil {
+		return err
+	}
+	for _, v := range ret {
+		callback(v[0], v[1])
+	}
+	return nil
+}
+
+// PredictiveSearchCallback finds keywords starting with the prefix and callback with the keyword and it's id in the order of the keywords.
+func (a *MmapedDoubleArray) PredictiveSearchCallback(prefix string, callback func(key string, id int)) error {
+	return predictiveSearch(a.Keys(prefix), callback)
+}
+
+// Keys returns the iterator of the keywords starting with the prefix.
+// The iterator returns ErrClosed by Err if the double array is closed while iterating.
+func (a *MmapedDoubleArray) Keys(prefix string) *KeyIterator {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.raw == nil {
+		return NewErrKeyIterator(ErrClosed)
+	}
+	return newKeyIterator(a.lockedAt64, uint64(len(a.raw)/unitSize), prefix)
+}
+
+// lockedAt64 is at64 guarded against Close for the iterators.
+func (a *MmapedDoubleArray) lockedAt64(i uint64) (unit64, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.raw == nil {
+		return 0, ErrClosed
+	}
+	return a.at64(i)
+}
+
+func (a *MmapedDoubleArray) at64(i uint64) (unit64, error) {
+	if i >= uint64(len(a.raw)/unitSize) {
+		return 0, fmt.Errorf("index out of bounds")
+	}
+	u, err := a.at(uint32(i))
+	return u.unit64(), err
+}
+
+// Close deletes the mapped memory and closes the opened file.
+// It waits for the searches in flight, which never hold the lock against Close while calling the callbacks.
+func (a *MmapedDoubleArray) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.data == nil {
+		return nil
+	}
+	data := a.data
+	a.data = nil
+	a.raw = nil
+	runtime.SetFinalizer(a, nil)
+	return unmap(data)
+}
+
+func openMmapUint64(f *os.File, offset int64, size int, opts mmapOptions) (*MmapedDoubleArrayUint64, error) {
+	if size < len(uint64Header) || (size-len(uint64Header))%unit64Size != 0 {
+		return nil, fmt.Errorf("invalid file size, %v", size)
+	}
+	b, raw, err := mmap(f, offset, size)
+	if err != nil {
+		return nil, err
+	}
+	if string(raw[:len(uint64Header)]) != uint64Header {
+		unmap(b)
+		return nil, fmt.Errorf("invalid header, not a double array of the 64-bit layout")
+	}
+	if err := opts.apply(b); err != nil {
+		unmap(b)
+		return nil, err
+	}
+	ret := &MmapedDoubleArrayUint64{
+		data: b,
+		raw:  raw[len(uint64Header):],
+	}
+	runtime.SetFinalizer(ret, (*MmapedDoubleArrayUint64).Close)
+	opts.warmupAfterOpen(ret)
+	return ret, nil
+}
+
+// Close deletes the mapped memory and closes the opened file.
+// It waits for the searches in flight, which never hold the lock against Close while calling the callbacks.
+func (a *MmapedDoubleArrayUint64) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.data == nil {
+		return nil
+	}
+	data := a.data
+	a.data = nil
+	a.raw = nil
+	runtime.SetFinalizer(a, nil)
+	return unmap(data)
+}
+
+func (a *MmapedDoubleArrayUint64) at(i uint64) (unit64, error) {
+	if i >= uint64(len(a.raw)/unit64Size) {
+		return 0, fmt.Errorf("index out of bounds")
+	}
+	ret := binary.LittleEndian.Uint64(a.raw[i*unit64Size : (i+1)*unit64Size])
+	return unit64(ret), nil
+}
+
+// ExactMatchSearch searches TRIE by a given keyword and returns the id and it's length if found.
+func (a *MmapedDoubleArrayUint64) ExactMatchSearch(key string) (id, size int, err error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.raw == nil {
+		return -1, -1, ErrClosed
+	}
+	return exactMatchSearch(units[uint64]{raw: a.raw}, key)
+}
+
+// CommonPrefixSearch finds keywords sharing common prefix in an input and returns the array of pairs (id and it's length) if found.
+func (a *MmapedDoubleArrayUint64) CommonPrefixSearch(key string, offset int) ([][2]int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.raw == nil {
+		return nil, ErrClosed
+	}
+	return commonPrefixSearch(units[uint64]{raw: a.raw}, key, offset)
+}
+
+// CommonPrefixSearchCallback finds keywords sharing common prefix in an input and callback with id and it's length.
+// The callback is called after the search releases the lock against Close,
+// so it may search the double array again or close it.
+func (a *MmapedDoubleArrayUint64) CommonPrefixSearchCallback(key string, offset int, callback func(id, size int)) error {
+	ret, err := a.CommonPrefixSearch(key, offset)
+	if err != nil {
+		return err
+	}
+	for _, v := range ret {
+		callback(v[0], v[1])
+	}
+	return nil
+}
+
+// PredictiveSearchCallback finds keywords starting with the prefix and callback with the keyword and it's id in the order of the keywords.
+func (a *MmapedDoubleArrayUint64) PredictiveSearchCallback(prefix string, callback func(key string, id int)) error {
+	return predictiveSearch(a.Keys(prefix), callback)
+}
+
+// Keys returns the iterator of the keywords starting with the prefix.
+// The iterator returns ErrClosed by Err if the double array is closed while iterating.
+func (a *MmapedDoubleArrayUint64) Keys(prefix string) *KeyIterator {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.raw == nil {
+		return NewErrKeyIterator(ErrClosed)
+	}
+	return newKeyIterator(a.lockedAt, uint64(len(a.raw)/unit64Size), prefix)
+}
+
+// lockedAt is at guarded against Close for the iterators.
+func (a *MmapedDoubleArrayUint64) lockedAt(i uint64) (unit64, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.raw == nil {
+		return 0, ErrClosed
+	}
+	return a.at(i)
+}
+
+// OpenMmapedSection opens the named file and maps the double array in the section of it on the memory.
+// The offset need not be a multiple of the page size.
+func OpenMmapedSection(name string, offset, size int64, opts ...MmapOption) (*MmapedDoubleArray, error) {
+	f, err := openSection(name, offset, size)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return openMmap(f, offset, int(size), newMmapOptions(opts))
+}
+
+// OpenMmapedSectionUint64 opens the named file and maps the double array of the 64-bit layout in the section of it on the memory.
+// The offset need not be a multiple of the page size.
+func OpenMmapedSectionUint64(name string, offset, size int64, opts ...MmapOption) (*MmapedDoubleArrayUint64, error) {
+	f, err := openSection(name, offset, size)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return openMmapUint64(f, offset, int(size), newMmapOptions(opts))
+}
+
+// Warmup touches all pages of the mapped memory to fault them in.
+func (a *MmapedDoubleArray) Warmup() error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.data == nil {
+		return ErrClosed
+	}
+	warmup(a.data)
+	return nil
+}
+
+// Warmup touches all pages of the mapped memory to fault them in.
+func (a *MmapedDoubleArrayUint64) Warmup() error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.data == nil {
+		return ErrClosed
+	}
+	warmup(a.data)
+	return nil
+}
+
+// WriteDOT writes the units reachable from the node of the prefix in the Graphviz DOT language.
+func (a *MmapedDoubleArray) WriteDOT(w io.Writer, prefix string) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.raw == nil {
+		return ErrClosed
+	}
+	return writeDOT(w, a.at64, prefix)
+}
+
+// WriteDOT writes the units reachable from the node of the prefix in the Graphviz DOT language.
+func (a *MmapedDoubleArrayUint64) WriteDOT(w io.Writer, prefix string) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.raw == nil {
+		return ErrClosed
+	}
+	return writeDOT(w, a.at, prefix)
+}
+
+// DumpUnits writes the units decoded one per line for debugging.
+func (a *MmapedDoubleArray) DumpUnits(w io.Writer) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.raw == nil {
+		return ErrClosed
+	}
+	return dumpUnits(w, a.at64, uint64(len(a.raw)/unitSize), func(i uint64) (uint64, error) {
+		u, err := a.at(uint32(i))
+		return uint64(u), err
+	}, true)
+}
+
+// DumpUnits writes the units decoded one per line for debugging.
+func (a *MmapedDoubleArrayUint64) DumpUnits(w io.Writer) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.raw == nil {
+		return ErrClosed
+	}
+	return dumpUnits(w, a.at, uint64(len(a.raw)/unit64Size), func(i uint64) (uint64, error) {
+		u, err := a.at(i)
+		return uint64(u), err
+	}, false)
+}
+
+// Validate walks the double array from the root and returns the ValidationError if the structure is broken.
+func (a *MmapedDoubleArray) Validate() error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.raw == nil {
+		return ErrClosed
+	}
+	return validate(a.at64, uint64(len(a.raw)/unitSize))
+}
+
+// Validate walks the double array from the root and returns the ValidationError if the structure is broken.
+func (a *MmapedDoubleArrayUint64) Validate() error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.raw == nil {
+		return ErrClosed
+	}
+	return validate(a.at, uint64(len(a.raw)/unit64Size))
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris windows
+
+package internal
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func init() {
+	searcherFactories["mmap 32-bit"] = func(t *testing.T, keys []string, values []uint32) (searcher, func()) {
+		name := writeDoubleArray(t, &DoubleArrayBuilder{}, keys, values)
+		a, err := OpenMmaped(name)
+		if err != nil {
+			os.Remove(name)
+			t.Fatalf("unexpected error, %v", err)
+		}
+		return a, func() {
+			a.Close()
+			os.Remove(name)
+		}
+	}
+	searcherFactories["mmap 64-bit"] = func(t *testing.T, keys []string, values []uint32) (searcher, func()) {
+		name := writeDoubleArray(t, &DoubleArrayBuilder{isUint64: true}, keys, values)
+		a, err := OpenMmapedUint64(name)
+		if err != nil {
+			os.Remove(name)
+			t.Fatalf("unexpected error, %v", err)
+		}
+		return a, func() {
+			a.Close()
+			os.Remove(name)
+		}
+	}
+}
+
+// writeDoubleArray builds the double array and writes it to a temporary file, and returns the name of the file.
+func writeDoubleArray(t *testing.T, b *DoubleArrayBuilder, keys []string, values []uint32) string {
+	if err := b.Build(keys, values); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	f, err := ioutil.TempFile("", "da_conformance_test")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer f.Close()
+	if _, err := b.WriteTo(f); err != nil {
+		os.Remove(f.Name())
+		t.Fatalf("unexpected error, %v", err)
+	}
+	return f.Name()
+}
@@ -12,14 +12,19 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// +build mmap
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
 
 package internal
 
 import (
 	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestMmapedDoubleArray_ExactMatchSearch(t *testing.T) {
@@ -202,3 +207,377 @@ func TestMmapedDoubleArray_At(t *testing.T) {
 		}
 	})
 }
+
+func TestMmapedDoubleArrayUint64(t *testing.T) {
+	keys := []string{
+		"hello",
+		"world",
+		"電気",
+		"電気通信",
+		"電気通信大学",
+		"電気通信大学大学院",
+		"電気通信大学大学院大学",
+	}
+	builder := DoubleArrayBuilder{isUint64: true}
+	if err := builder.Build(keys, nil); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	fp, err := ioutil.TempFile("", "da_mmap_uint64_test")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer os.Remove(fp.Name())
+	if _, err := builder.WriteTo(fp); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	fp.Close()
+
+	da, err := OpenMmapedUint64(fp.Name())
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer func() {
+		if err := da.Close(); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+	}()
+	t.Run("exact match search", func(t *testing.T) {
+		for i, v := range keys {
+			id, size, err := da.ExactMatchSearch(v)
+			if err != nil {
+				t.Errorf("unexpected error, %v", err)
+			}
+			if id != i || size != len(v) {
+				t.Errorf("expected id=%v, size=%v, got id=%v, size=%v (%v)", i, len(v), id, size, v)
+			}
+		}
+	})
+	t.Run("common prefix search", func(t *testing.T) {
+		ret, err := da.CommonPrefixSearch("電気通信大学大学院大学", 0)
+		if err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if expected := [][2]int{{2, 6}, {3, 12}, {4, 18}, {5, 27}, {6, 33}}; !reflect.DeepEqual(expected, ret) {
+			t.Errorf("expected %v, got %v", expected, ret)
+		}
+	})
+	t.Run("open 32-bit layout", func(t *testing.T) {
+		if _, err := OpenMmapedUint64("./_testdata/mmapbin_1_2_3_4_5"); err == nil {
+			t.Errorf("expected invalid header error")
+		}
+	})
+}
+
+type mmapedTrie interface {
+	ExactMatchSearch(key string) (id, size int, err error)
+	CommonPrefixSearch(key string, offset int) ([][2]int, error)
+	CommonPrefixSearchCallback(key string, offset int, callback func(id, size int)) error
+	PredictiveSearchCallback(prefix string, callback func(key string, id int)) error
+	Keys(prefix string) *KeyIterator
+	Close() error
+}
+
+func TestMmapedDoubleArray_CloseConcurrently(t *testing.T) {
+	keys := []string{"a", "ab", "abc", "b", "bc", "c"}
+	for _, layout := range []struct {
+		name   string
+		uint64 bool
+		open   func(name string) (mmapedTrie, error)
+	}{
+		{
+			name: "32-bit",
+			open: func(name string) (mmapedTrie, error) { return OpenMmaped(name) },
+		},
+		{
+			name:   "64-bit",
+			uint64: true,
+			open:   func(name string) (mmapedTrie, error) { return OpenMmapedUint64(name) },
+		},
+	} {
+		t.Run(layout.name, func(t *testing.T) {
+			builder := DoubleArrayBuilder{isUint64: layout.uint64}
+			if err := builder.Build(keys, nil); err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			fp, err := ioutil.TempFile("", "da_mmap_close_test")
+			if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			defer os.Remove(fp.Name())
+			if _, err := builder.WriteTo(fp); err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			fp.Close()
+
+			for i := 0; i < 10; i++ {
+				da, err := layout.open(fp.Name())
+				if err != nil {
+					t.Fatalf("unexpected error, %v", err)
+				}
+				var wg sync.WaitGroup
+				start := make(chan struct{})
+				for j := 0; j < 4; j++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						<-start
+						for {
+							if _, _, err := da.ExactMatchSearch("abc"); err == ErrClosed {
+								break
+							} else if err != nil {
+								t.Errorf("unexpected error, %v", err)
+								return
+							}
+							if _, err := da.CommonPrefixSearch("abc", 0); err != nil && err != ErrClosed {
+								t.Errorf("unexpected error, %v", err)
+								return
+							}
+							it := da.Keys("")
+							for it.Next() {
+							}
+							if err := it.Err(); err != nil && err != ErrClosed {
+								t.Errorf("unexpected error, %v", err)
+								return
+							}
+						}
+					}()
+				}
+				close(start)
+				time.Sleep(time.Millisecond)
+				if err := da.Close(); err != nil {
+					t.Errorf("unexpected error, %v", err)
+				}
+				wg.Wait()
+				if err := da.Close(); err != nil {
+					t.Errorf("unexpected error, %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestMmapedDoubleArray_SearchInCallback(t *testing.T) {
+	keys := []string{"a", "ab", "abc", "b", "bc", "c"}
+	for _, layout := range []struct {
+		name   string
+		uint64 bool
+		open   func(name string) (mmapedTrie, error)
+	}{
+		{
+			name: "32-bit",
+			open: func(name string) (mmapedTrie, error) { return OpenMmaped(name) },
+		},
+		{
+			name:   "64-bit",
+			uint64: true,
+			open:   func(name string) (mmapedTrie, error) { return OpenMmapedUint64(name) },
+		},
+	} {
+		t.Run(layout.name, func(t *testing.T) {
+			name := writeDoubleArray(t, &DoubleArrayBuilder{isUint64: layout.uint64}, keys, nil)
+			defer os.Remove(name)
+			for _, search := range []struct {
+				name string
+				run  func(da mmapedTrie, callback func()) error
+			}{
+				{
+					name: "common prefix search",
+					run: func(da mmapedTrie, callback func()) error {
+						return da.CommonPrefixSearchCallback("abc", 0, func(int, int) { callback() })
+					},
+				},
+				{
+					name: "predictive search",
+					run: func(da mmapedTrie, callback func()) error {
+						return da.PredictiveSearchCallback("a", func(string, int) { callback() })
+					},
+				},
+			} {
+				da, err := layout.open(name)
+				if err != nil {
+					t.Fatalf("unexpected error, %v", err)
+				}
+				done := make(chan error, 1)
+				go func() {
+					var calls int
+					err := search.run(da, func() {
+						calls++
+						if calls != 1 {
+							return
+						}
+						if _, _, err := da.ExactMatchSearch("ab"); err != nil {
+							t.Errorf("%v: unexpected error, %v", search.name, err)
+						}
+						if err := da.Close(); err != nil {
+							t.Errorf("%v: unexpected error, %v", search.name, err)
+						}
+						if _, _, err := da.ExactMatchSearch("ab"); err != ErrClosed {
+							t.Errorf("%v: expected ErrClosed, got %v", search.name, err)
+						}
+					})
+					done <- err
+				}()
+				select {
+				case err := <-done:
+					if err != nil && err != ErrClosed {
+						t.Errorf("%v: unexpected error, %v", search.name, err)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("%v: deadlock, the callback is called with the lock", search.name)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenMmaped_Options(t *testing.T) {
+	builder := DoubleArrayBuilder{}
+	if err := builder.Build([]string{"a", "ab", "abc"}, nil); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	fp, err := ioutil.TempFile("", "da_mmap_options_test")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer os.Remove(fp.Name())
+	if _, err := builder.WriteTo(fp); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	fp.Close()
+
+	testCases := []struct {
+		name string
+		opts []MmapOption
+	}{
+		{name: "random", opts: []MmapOption{MmapRandom()}},
+		{name: "will need", opts: []MmapOption{MmapWillNeed()}},
+		{name: "warmup", opts: []MmapOption{MmapWarmup(false)}},
+		{name: "warmup in background", opts: []MmapOption{MmapRandom(), MmapWarmup(true)}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			da, err := OpenMmaped(fp.Name(), tc.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			if id, _, err := da.ExactMatchSearch("ab"); err != nil || id != 1 {
+				t.Errorf("expected id=1, got id=%v, err=%v", id, err)
+			}
+			if err := da.Warmup(); err != nil {
+				t.Errorf("unexpected error, %v", err)
+			}
+			if err := da.Close(); err != nil {
+				t.Errorf("unexpected error, %v", err)
+			}
+			if err := da.Warmup(); err != ErrClosed {
+				t.Errorf("expected ErrClosed, got %v", err)
+			}
+		})
+	}
+	t.Run("mmap error", func(t *testing.T) {
+		empty, err := ioutil.TempFile("", "da_mmap_empty_test")
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		defer os.Remove(empty.Name())
+		empty.Close()
+		// an empty file can not be mapped on the memory.
+		if _, err := OpenMmaped(empty.Name(), MmapRandom()); err == nil {
+			t.Errorf("expected error")
+		} else if _, ok := err.(*MmapError); !ok {
+			t.Errorf("expected MmapError, got %T, %v", err, err)
+		}
+	})
+	t.Run("lock", func(t *testing.T) {
+		da, err := OpenMmaped(fp.Name(), MmapLock())
+		if err != nil {
+			// mlock fails if RLIMIT_MEMLOCK is too small.
+			t.Skipf("mlock, %v", err)
+		}
+		if err := da.Close(); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+	})
+}
+
+func TestOpenMmapedSection(t *testing.T) {
+	keys := []string{"a", "ab", "abc", "hello", "world", "電気通信"}
+	var da32, da64 bytes.Buffer
+	for _, v := range []struct {
+		builder *DoubleArrayBuilder
+		w       *bytes.Buffer
+	}{
+		{builder: &DoubleArrayBuilder{}, w: &da32},
+		{builder: &DoubleArrayBuilder{isUint64: true}, w: &da64},
+	} {
+		if err := v.builder.Build(keys, nil); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if _, err := v.builder.WriteTo(v.w); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+	}
+	for _, offset := range []int{0, 3, os.Getpagesize(), os.Getpagesize() + 5} {
+		fp, err := ioutil.TempFile("", "da_mmap_section_test")
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		defer os.Remove(fp.Name())
+		// header, 32-bit layout, 64-bit layout, trailer
+		fp.Write(bytes.Repeat([]byte{0xFF}, offset))
+		fp.Write(da32.Bytes())
+		fp.Write(da64.Bytes())
+		fp.Write([]byte("trailer"))
+		fp.Close()
+
+		t.Run(fmt.Sprintf("32-bit layout at %v", offset), func(t *testing.T) {
+			da, err := OpenMmapedSection(fp.Name(), int64(offset), int64(da32.Len()))
+			if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			defer da.Close()
+			testMmapedSection(t, da, keys)
+		})
+		t.Run(fmt.Sprintf("64-bit layout at %v", offset+da32.Len()), func(t *testing.T) {
+			da, err := OpenMmapedSectionUint64(fp.Name(), int64(offset+da32.Len()), int64(da64.Len()))
+			if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			defer da.Close()
+			testMmapedSection(t, da, keys)
+		})
+		t.Run(fmt.Sprintf("out of the file at %v", offset), func(t *testing.T) {
+			if _, err := OpenMmapedSection(fp.Name(), int64(offset), int64(offset+da32.Len()+da64.Len()+8)); err == nil {
+				t.Errorf("expected section out of the file error")
+			}
+		})
+	}
+	t.Run("negative offset", func(t *testing.T) {
+		if _, err := OpenMmapedSection("./_testdata/mmapbin_1_2_3_4_5", -1, 4); err == nil {
+			t.Errorf("expected invalid section error")
+		}
+	})
+}
+
+func testMmapedSection(t *testing.T, da mmapedTrie, keys []string) {
+	t.Helper()
+	for i, v := range keys {
+		if id, size, err := da.ExactMatchSearch(v); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		} else if id != i || size != len(v) {
+			t.Errorf("expected id=%v, size=%v, got id=%v, size=%v (%v)", i, len(v), id, size, v)
+		}
+	}
+	var got []string
+	it := da.Keys("")
+	defer it.Close()
+	for it.Next() {
+		got = append(got, it.Key())
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if !reflect.DeepEqual(keys, got) {
+		t.Errorf("expected %v, got %v", keys, got)
+	}
+}
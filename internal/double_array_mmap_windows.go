@@ -12,173 +12,49 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// +build mmap
-
 package internal
 
 import (
-	"encoding/binary"
 	"fmt"
 	"os"
-	"runtime"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
 )
 
-const (
-	MmapedFileHeaderSize = 8
-)
+// allocationGranularity is the alignment of the offset of the view of the file mapping.
+const allocationGranularity = 64 * 1024
 
-// MmapedDoubleArray represents the TRIE data structure mapped on the virtual memory address.
-type MmapedDoubleArray struct {
-	raw []byte
-}
-
-// OpenMmaped opens the named file of double array and maps it on the memory.
-func OpenMmaped(name string) (*MmapedDoubleArray, error) {
-	f, err := os.Open(name)
+// mmap maps the section of the file from the allocation granularity boundary including the offset,
+// and returns the mapped memory and the section in it.
+func mmap(f *os.File, offset int64, size int) (data, section []byte, err error) {
+	delta := int(offset % allocationGranularity)
+	start, end := offset-int64(delta), offset+int64(size)
+	fm, err := windows.CreateFileMapping(windows.Handle(f.Fd()), nil, windows.PAGE_READONLY, uint32(end>>32), uint32(end), nil)
 	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	info, err := f.Stat()
-	if err != nil {
-		return nil, err
-	}
-	size := info.Size()
-	if size != int64(int(size)) {
-		return nil, fmt.Errorf("too large file")
-	}
-	return openMmap(f, 0, int(size))
-}
-
-func openMmap(f *os.File, offset, size int) (*MmapedDoubleArray, error) {
-	if int64(offset)%int64(os.Getpagesize()) != 0 {
-		return nil, fmt.Errorf("offset parameter must be a multiple of the system's page size")
-	}
-	low, high := uint32(size), uint32(size>>32)
-	fm, err := windows.CreateFileMapping(windows.Handle(f.Fd()), nil, windows.PAGE_READONLY, high, low, nil)
-	if err != nil {
-		return nil, err
+		return nil, nil, &MmapError{Err: err}
 	}
 	defer windows.CloseHandle(fm)
-	ptr, err := windows.MapViewOfFile(fm, windows.FILE_MAP_READ, 0, 0, uintptr(size))
-	if err != nil {
-		return nil, err
-	}
-	b := (*[maxBytes]byte)(unsafe.Pointer(ptr))[:size]
-
-	ret := &MmapedDoubleArray{
-		raw: b,
-	}
-	runtime.SetFinalizer(ret, (*MmapedDoubleArray).Close)
-	return ret, nil
-}
-
-func (a MmapedDoubleArray) at(i uint32) (unit, error) {
-	if int(i+1)*unitSize > len(a.raw) {
-		return 0, fmt.Errorf("index out of bounds")
-	}
-	ret := binary.LittleEndian.Uint32(a.raw[i*unitSize : (i+1)*unitSize])
-	return unit(ret), nil
-}
-
-// ExactMatchSearch searches TRIE by a given keyword and returns the id and it's length if found.
-func (a MmapedDoubleArray) ExactMatchSearch(key string) (id, size int, err error) {
-	nodePos := uint32(0)
-	unit, err := a.at(nodePos)
+	ptr, err := windows.MapViewOfFile(fm, windows.FILE_MAP_READ, uint32(start>>32), uint32(start), uintptr(size+delta))
 	if err != nil {
-		return -1, -1, err
-	}
-	for i := 0; i < len(key); i++ {
-		nodePos ^= unit.offset() ^ uint32(key[i])
-		unit, err = a.at(nodePos)
-		if err != nil {
-			return -1, -1, err
-		}
-		if unit.label() != key[i] {
-			return -1, 0, nil
-		}
+		return nil, nil, &MmapError{Err: err}
 	}
-	if !unit.hasLeaf() {
-		return -1, 0, nil
-	}
-	unit, err = a.at(nodePos ^ unit.offset())
-	if err != nil {
-		return -1, -1, err
-	}
-	return int(unit.value()), len(key), nil
+	data = (*[maxBytes]byte)(unsafe.Pointer(ptr))[:size+delta]
+	return data, data[delta:], nil
 }
 
-// CommonPrefixSearch finds keywords sharing common prefix in an input and returns the array of pairs (id and it's length) if found.
-func (a MmapedDoubleArray) CommonPrefixSearch(key string, offset int) ([][2]int, error) {
-	var ret [][2]int
-	nodePos := uint32(0)
-	unit, err := a.at(nodePos)
-	if err != nil {
-		return ret, err
-	}
-	nodePos ^= unit.offset()
-	for i := offset; i < len(key); i++ {
-		k := key[i]
-		nodePos ^= uint32(k)
-		unit, err := a.at(nodePos)
-		if err != nil {
-			return ret, err
-		}
-		if unit.label() != k {
-			break
-		}
-		nodePos ^= unit.offset()
-		if unit.hasLeaf() {
-			u, err := a.at(nodePos)
-			if err != nil {
-				return ret, err
-			}
-			ret = append(ret, [2]int{int(u.value()), i + 1})
-		}
-	}
-	return ret, nil
+// unmap deletes the mapped memory.
+func unmap(data []byte) error {
+	return windows.UnmapViewOfFile(uintptr(unsafe.Pointer(&data[0])))
 }
 
-// CommonPrefixSearchCallback finds keywords sharing common prefix in an input and callback with id and it's length.
-func (a MmapedDoubleArray) CommonPrefixSearchCallback(key string, offset int, callback func(id, size int)) error {
-	nodePos := uint32(0)
-	unit, err := a.at(nodePos)
-	if err != nil {
-		return err
+// apply checks the options, the advices are ignored on windows.
+func (o mmapOptions) apply(b []byte) error {
+	if o.hugePage {
+		return fmt.Errorf("huge page advice is not supported on windows")
 	}
-	nodePos ^= unit.offset()
-	for i := offset; i < len(key); i++ {
-		k := key[i]
-		nodePos ^= uint32(k)
-		unit, err := a.at(nodePos)
-		if err != nil {
-			return err
-		}
-		if unit.label() != k {
-			break
-		}
-		nodePos ^= unit.offset()
-		if unit.hasLeaf() {
-			u, err := a.at(nodePos)
-			if err != nil {
-				return err
-			}
-			callback(int(u.value()), i+1)
-		}
+	if o.lock {
+		return fmt.Errorf("lock is not supported on windows")
 	}
 	return nil
 }
-
-// Close deletes the mapped memory and closes the opened file.
-func (a *MmapedDoubleArray) Close() error {
-	if a.raw == nil {
-		return nil
-	}
-	data := a.raw
-	a.raw = nil
-	runtime.SetFinalizer(a, nil)
-	return windows.UnmapViewOfFile(uintptr(unsafe.Pointer(&data[0])))
-}
@@ -12,8 +12,6 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// +build mmap
-
 package internal
 
 const maxBytes = 1<<50 - 1
@@ -17,6 +17,7 @@ package internal
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
 	"os"
 )
 
@@ -36,15 +37,60 @@ func Open(name string) (*DoubleArrayUint32, error) {
 	if err != nil {
 		return nil, err
 	}
-	size := info.Size()
+	return readDoubleArray(f, info.Size())
+}
+
+// OpenSection opens the named file and reads the double array in the section of it.
+func OpenSection(name string, offset, size int64) (*DoubleArrayUint32, error) {
+	f, err := openSection(name, offset, size)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readDoubleArray(io.NewSectionReader(f, offset, size), size)
+}
+
+// openSection opens the named file and checks the section is in the file.
+func openSection(name string, offset, size int64) (*os.File, error) {
+	if offset < 0 || size < 0 {
+		return nil, fmt.Errorf("invalid section, offset=%v, size=%v", offset, size)
+	}
+	if size != int64(int(size)) {
+		return nil, fmt.Errorf("too large section")
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if offset+size > info.Size() {
+		f.Close()
+		return nil, fmt.Errorf("section out of the file, offset=%v, size=%v, file size=%v", offset, size, info.Size())
+	}
+	return f, nil
+}
+
+func readDoubleArray(r io.ReadSeeker, size int64) (*DoubleArrayUint32, error) {
 	if size != int64(int(size)) {
 		return nil, fmt.Errorf("too large file")
 	}
+	if ok, err := isUint64(r); err != nil {
+		return nil, err
+	} else if ok {
+		return nil, fmt.Errorf("invalid header, the double array of the 64-bit layout")
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
 	var ret DoubleArrayUint32
 	ret.array = make([]uint32, 0, size/4)
 	for i := int64(0); i < size; i += 4 {
 		var u uint32
-		if err := binary.Read(f, binary.LittleEndian, &u); err != nil {
+		if err := binary.Read(r, binary.LittleEndian, &u); err != nil {
 			return nil, fmt.Errorf("broken array, %v", err)
 		}
 		ret.array = append(ret.array, u)
@@ -61,88 +107,33 @@ func (a DoubleArrayUint32) at(i uint32) (unit, error) {
 
 // ExactMatchSearch searches TRIE by a given keyword and returns the id and it's length if found.
 func (a DoubleArrayUint32) ExactMatchSearch(key string) (id, size int, err error) {
-	nodePos := uint32(0)
-	unit, err := a.at(nodePos)
-	if err != nil {
-		return -1, -1, err
-	}
-	for i := 0; i < len(key); i++ {
-		nodePos ^= unit.offset() ^ uint32(key[i])
-		unit, err = a.at(nodePos)
-		if err != nil {
-			return -1, -1, err
-		}
-		if unit.label() != key[i] {
-			return -1, 0, nil
-		}
-	}
-	if !unit.hasLeaf() {
-		return -1, 0, nil
-	}
-	unit, err = a.at(nodePos ^ unit.offset())
-	if err != nil {
-		return -1, -1, err
-	}
-	return int(unit.value()), len(key), nil
+	return exactMatchSearch(units[uint32]{words: a.array}, key)
 }
 
 // CommonPrefixSearch finds keywords sharing common prefix in an input and returns the array of pairs (id and it's length) if found.
 func (a DoubleArrayUint32) CommonPrefixSearch(key string, offset int) ([][2]int, error) {
-	var ret [][2]int
-	nodePos := uint32(0)
-	unit, err := a.at(nodePos)
-	if err != nil {
-		return ret, err
-	}
-	nodePos ^= unit.offset()
-	for i := offset; i < len(key); i++ {
-		k := key[i]
-		nodePos ^= uint32(k)
-		unit, err := a.at(nodePos)
-		if err != nil {
-			return ret, err
-		}
-		if unit.label() != k {
-			break
-		}
-		nodePos ^= unit.offset()
-		if unit.hasLeaf() {
-			u, err := a.at(nodePos)
-			if err != nil {
-				return ret, err
-			}
-			ret = append(ret, [2]int{int(u.value()), i + 1})
-		}
-	}
-	return ret, nil
+	return commonPrefixSearch(units[uint32]{words: a.array}, key, offset)
 }
 
 // CommonPrefixSearchCallback finds keywords sharing common prefix in an input and callback with id and it's length.
 func (a DoubleArrayUint32) CommonPrefixSearchCallback(key string, offset int, callback func(id, size int)) error {
-	nodePos := uint32(0)
-	unit, err := a.at(nodePos)
-	if err != nil {
-		return err
-	}
-	nodePos ^= unit.offset()
-	for i := offset; i < len(key); i++ {
-		k := key[i]
-		nodePos ^= uint32(k)
-		unit, err := a.at(nodePos)
-		if err != nil {
-			return err
-		}
-		if unit.label() != k {
-			break
-		}
-		nodePos ^= unit.offset()
-		if unit.hasLeaf() {
-			u, err := a.at(nodePos)
-			if err != nil {
-				return err
-			}
-			callback(int(u.value()), i+1)
-		}
+	return commonPrefixSearchCallback(units[uint32]{words: a.array}, key, offset, callback)
+}
+
+// PredictiveSearchCallback finds keywords starting with the prefix and callback with the keyword and it's id in the order of the keywords.
+func (a DoubleArrayUint32) PredictiveSearchCallback(prefix string, callback func(key string, id int)) error {
+	return predictiveSearch(a.Keys(prefix), callback)
+}
+
+// Keys returns the iterator of the keywords starting with the prefix.
+func (a DoubleArrayUint32) Keys(prefix string) *KeyIterator {
+	return newKeyIterator(a.at64, uint64(len(a.array)), prefix)
+}
+
+func (a DoubleArrayUint32) at64(i uint64) (unit64, error) {
+	if i >= uint64(len(a.array)) {
+		return 0, fmt.Errorf("index out of bounds")
 	}
-	return nil
+	u, err := a.at(uint32(i))
+	return u.unit64(), err
 }
@@ -124,6 +124,53 @@ func TestDoubleArrayUint32_CommonPrefixSearchCallback(t *testing.T) {
 	})
 }
 
+func TestDoubleArrayUint32_PredictiveSearchCallback(t *testing.T) {
+	keys := []string{
+		"a",
+		"ab",
+		"abc",
+		"b",
+		"bc",
+		"電気",
+		"電気通信",
+		"電気通信大学",
+	}
+	// the values collide with the labels of the keys.
+	values := []uint32{'a', 'b', 'c', 'a', 'b', 'c', 0xE9, 0x80}
+	a, err := BuildDoubleArray(keys, values, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	testCases := []struct {
+		prefix string
+		keys   []string
+		ids    []int
+	}{
+		{prefix: "", keys: keys, ids: []int{'a', 'b', 'c', 'a', 'b', 'c', 0xE9, 0x80}},
+		{prefix: "a", keys: []string{"a", "ab", "abc"}, ids: []int{'a', 'b', 'c'}},
+		{prefix: "bc", keys: []string{"bc"}, ids: []int{'b'}},
+		{prefix: "電気通", keys: []string{"電気通信", "電気通信大学"}, ids: []int{0xE9, 0x80}},
+		{prefix: "c"},
+		{prefix: "abcd"},
+	}
+	for _, tc := range testCases {
+		var keys []string
+		var ids []int
+		if err := a.PredictiveSearchCallback(tc.prefix, func(key string, id int) {
+			keys = append(keys, key)
+			ids = append(ids, id)
+		}); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if !reflect.DeepEqual(tc.keys, keys) {
+			t.Errorf("keys: expected %v, got %v (%v)", tc.keys, keys, tc.prefix)
+		}
+		if !reflect.DeepEqual(tc.ids, ids) {
+			t.Errorf("ids: expected %v, got %v (%v)", tc.ids, ids, tc.prefix)
+		}
+	}
+}
+
 func TestOpen(t *testing.T) {
 	f, err := os.Open("./_testdata/keys.txt")
 	if err != nil {
@@ -156,3 +203,56 @@ func TestOpen(t *testing.T) {
 		}
 	}
 }
+
+func BenchmarkDoubleArrayUint32(b *testing.B) {
+	f, err := os.Open("./_testdata/keys.txt")
+	if err != nil {
+		b.Fatalf("unexpected open file error, %v", err)
+	}
+	defer f.Close()
+	var keys []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		keys = append(keys, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		b.Fatalf("unexpected scanner error, %v", err)
+	}
+	sort.Strings(keys)
+	uniq := keys[:0]
+	for i, v := range keys {
+		if i == 0 || v != keys[i-1] {
+			uniq = append(uniq, v)
+		}
+	}
+	keys = uniq
+
+	a, err := BuildDoubleArray(keys, nil, nil)
+	if err != nil {
+		b.Fatalf("unexpected error, %v", err)
+	}
+	b.Run("exact match search", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			v := keys[i%len(keys)]
+			if id, _, err := a.ExactMatchSearch(v); id < 0 || err != nil {
+				b.Fatalf("unexpected error, missing a keyword %v, id=%v, err=%v", v, id, err)
+			}
+		}
+	})
+	b.Run("common prefix search", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			v := keys[i%len(keys)]
+			if ret, err := a.CommonPrefixSearch(v, 0); len(ret) == 0 || err != nil {
+				b.Fatalf("unexpected error, missing a keyword %v, err=%v", v, err)
+			}
+		}
+	})
+	b.Run("common prefix search callback", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			v := keys[i%len(keys)]
+			if err := a.CommonPrefixSearchCallback(v, 0, func(id, size int) {}); err != nil {
+				b.Fatalf("unexpected error, %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,142 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// uint64Header is the header of the serialized double array of the 64-bit layout.
+// The first byte of the 32-bit layout is always the label of the root, 0, so the header never conflicts with it.
+const uint64Header = "DARTS64\x00"
+
+// DoubleArrayUint64 represents the TRIE data structure of the 64-bit layout.
+type DoubleArrayUint64 struct {
+	array []uint64
+}
+
+// IsUint64File returns true if the named file of the double array is the 64-bit layout.
+func IsUint64File(name string) (bool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	return isUint64(f)
+}
+
+// IsUint64FileSection returns true if the double array in the section of the named file is the 64-bit layout.
+func IsUint64FileSection(name string, offset, size int64) (bool, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	return isUint64(io.NewSectionReader(f, offset, size))
+}
+
+func isUint64(r io.Reader) (bool, error) {
+	var h [len(uint64Header)]byte
+	if _, err := io.ReadFull(r, h[:]); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return string(h[:]) == uint64Header, nil
+}
+
+// OpenUint64 opens the named file of the double array of the 64-bit layout.
+func OpenUint64(name string) (*DoubleArrayUint64, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return readDoubleArrayUint64(f, info.Size())
+}
+
+// OpenSectionUint64 opens the named file and reads the double array of the 64-bit layout in the section of it.
+func OpenSectionUint64(name string, offset, size int64) (*DoubleArrayUint64, error) {
+	f, err := openSection(name, offset, size)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readDoubleArrayUint64(io.NewSectionReader(f, offset, size), size)
+}
+
+func readDoubleArrayUint64(r io.Reader, size int64) (*DoubleArrayUint64, error) {
+	if size != int64(int(size)) {
+		return nil, fmt.Errorf("too large file")
+	}
+	if ok, err := isUint64(r); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, fmt.Errorf("invalid header, not a double array of the 64-bit layout")
+	}
+	size -= int64(len(uint64Header))
+	if size%unit64Size != 0 {
+		return nil, fmt.Errorf("invalid file size, %v", size)
+	}
+	var ret DoubleArrayUint64
+	ret.array = make([]uint64, 0, size/unit64Size)
+	for i := int64(0); i < size; i += unit64Size {
+		var u uint64
+		if err := binary.Read(r, binary.LittleEndian, &u); err != nil {
+			return nil, fmt.Errorf("broken array, %v", err)
+		}
+		ret.array = append(ret.array, u)
+	}
+	return &ret, nil
+}
+
+func (a DoubleArrayUint64) at(i uint64) (unit64, error) {
+	if i >= uint64(len(a.array)) {
+		return 0, fmt.Errorf("index out of bounds")
+	}
+	return unit64(a.array[i]), nil
+}
+
+// ExactMatchSearch searches TRIE by a given keyword and returns the id and it's length if found.
+func (a DoubleArrayUint64) ExactMatchSearch(key string) (id, size int, err error) {
+	return exactMatchSearch(units[uint64]{words: a.array}, key)
+}
+
+// CommonPrefixSearch finds keywords sharing common prefix in an input and returns the array of pairs (id and it's length) if found.
+func (a DoubleArrayUint64) CommonPrefixSearch(key string, offset int) ([][2]int, error) {
+	return commonPrefixSearch(units[uint64]{words: a.array}, key, offset)
+}
+
+// CommonPrefixSearchCallback finds keywords sharing common prefix in an input and callback with id and it's length.
+func (a DoubleArrayUint64) CommonPrefixSearchCallback(key string, offset int, callback func(id, size int)) error {
+	return commonPrefixSearchCallback(units[uint64]{words: a.array}, key, offset, callback)
+}
+
+// PredictiveSearchCallback finds keywords starting with the prefix and callback with the keyword and it's id in the order of the keywords.
+func (a DoubleArrayUint64) PredictiveSearchCallback(prefix string, callback func(key string, id int)) error {
+	return predictiveSearch(a.Keys(prefix), callback)
+}
+
+// Keys returns the iterator of the keywords starting with the prefix.
+func (a DoubleArrayUint64) Keys(prefix string) *KeyIterator {
+	return newKeyIterator(a.at, uint64(len(a.array)), prefix)
+}
@@ -0,0 +1,229 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestDoubleArrayUint64_ExactMatchSearch(t *testing.T) {
+	keys := []string{
+		"a",
+		"aa",
+		"b",
+		"cc",
+		"hello",
+		"world",
+		"こんにちは",
+	}
+	t.Run("keys", func(t *testing.T) {
+		a, err := BuildDoubleArrayUint64(keys, nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		for i, v := range keys {
+			id, size, err := a.ExactMatchSearch(v)
+			if err != nil {
+				t.Errorf("unexpected error, %v", err)
+			}
+			if id != i || size != len(v) {
+				t.Errorf("expected id=%v, size=%v, got id=%v, size=%v (%v)", i, len(v), id, size, string(v))
+			}
+		}
+	})
+	t.Run("keys and 32-bit ids", func(t *testing.T) {
+		ids := make([]uint32, len(keys))
+		for i := range keys {
+			ids[i] = 1<<32 - 1 - uint32(i*7)
+		}
+		a, err := BuildDoubleArrayUint64(keys, ids, nil)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		for i, v := range keys {
+			id, size, err := a.ExactMatchSearch(v)
+			if err != nil {
+				t.Errorf("unexpected error, %v", err)
+			}
+			if id != int(ids[i]) || size != len(v) {
+				t.Errorf("expected id=%v, size=%v, got id=%v, size=%v (%v)", ids[i], len(v), id, size, string(v))
+			}
+		}
+	})
+}
+
+func TestDoubleArrayUint64_CommonPrefixSearch(t *testing.T) {
+	keys := []string{
+		"hello",
+		"world",
+		"電気",
+		"電気通信",
+		"電気通信大学",
+		"電気通信大学大学院",
+		"電気通信大学大学院大学",
+	}
+	a, err := BuildDoubleArrayUint64(keys, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	t.Run("slice", func(t *testing.T) {
+		ret, err := a.CommonPrefixSearch("電気通信大学大学院大学", 0)
+		if err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if expected := [][2]int{{2, 6}, {3, 12}, {4, 18}, {5, 27}, {6, 33}}; !reflect.DeepEqual(expected, ret) {
+			t.Errorf("expected %v, got %v", expected, ret)
+		}
+	})
+	t.Run("callback", func(t *testing.T) {
+		var ids, sizes []int
+		if err := a.CommonPrefixSearchCallback("電気通信大学大学院大学", 0, func(id, size int) {
+			ids = append(ids, id)
+			sizes = append(sizes, size)
+		}); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if expected := []int{2, 3, 4, 5, 6}; !reflect.DeepEqual(expected, ids) {
+			t.Errorf("ids: expected %v, got %v", expected, ids)
+		}
+		if expected := []int{6, 12, 18, 27, 33}; !reflect.DeepEqual(expected, sizes) {
+			t.Errorf("sizes: expected %v, got %v", expected, sizes)
+		}
+	})
+}
+
+func TestDoubleArray_NULInQuery(t *testing.T) {
+	// the value of the leaf has the label 0 in the low byte, which must not match the NUL of the query.
+	keys := []string{"a"}
+	values := []uint32{256}
+	a32, err := BuildDoubleArray(keys, values, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	a64, err := BuildDoubleArrayUint64(keys, values, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	for _, a := range []interface {
+		ExactMatchSearch(key string) (id, size int, err error)
+		CommonPrefixSearch(key string, offset int) ([][2]int, error)
+	}{a32, a64} {
+		if id, _, err := a.ExactMatchSearch("a\x00"); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		} else if id != -1 {
+			t.Errorf("%T: expected not found, got id=%v", a, id)
+		}
+		ret, err := a.CommonPrefixSearch("a\x00", 0)
+		if err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if expected := [][2]int{{256, 1}}; !reflect.DeepEqual(ret, expected) {
+			t.Errorf("%T: expected %v, got %v", a, expected, ret)
+		}
+	}
+}
+
+func TestDoubleArrayUint64_PredictiveSearchCallback(t *testing.T) {
+	keys := []string{
+		"a",
+		"ab",
+		"abc",
+		"b",
+	}
+	values := []uint32{'a', 1<<32 - 1, 'c', 'b'}
+	a, err := BuildDoubleArrayUint64(keys, values, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	var got []string
+	var ids []int
+	if err := a.PredictiveSearchCallback("a", func(key string, id int) {
+		got = append(got, key)
+		ids = append(ids, id)
+	}); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if expected := []string{"a", "ab", "abc"}; !reflect.DeepEqual(expected, got) {
+		t.Errorf("keys: expected %v, got %v", expected, got)
+	}
+	maxValue := uint32(1<<32 - 1)
+	if expected := []int{'a', int(maxValue), 'c'}; !reflect.DeepEqual(expected, ids) {
+		t.Errorf("ids: expected %v, got %v", expected, ids)
+	}
+}
+
+func TestOpenUint64(t *testing.T) {
+	keys := []string{"a", "aa", "b", "cc", "hello", "world", "こんにちは"}
+	b := NewDoubleArrayBuilder(nil)
+	b.isUint64 = true
+	if err := b.Build(keys, nil); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	fp, err := ioutil.TempFile("", "da_uint64_test")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer os.Remove(fp.Name())
+	if _, err := b.WriteTo(fp); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	fp.Close()
+
+	t.Run("detect layout", func(t *testing.T) {
+		if ok, err := IsUint64File(fp.Name()); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		} else if !ok {
+			t.Errorf("expected the 64-bit layout")
+		}
+	})
+	t.Run("open", func(t *testing.T) {
+		a, err := OpenUint64(fp.Name())
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		for i, v := range keys {
+			if id, size, err := a.ExactMatchSearch(v); err != nil {
+				t.Errorf("unexpected error, %v", err)
+			} else if id != i || size != len(v) {
+				t.Errorf("expected id=%v, size=%v, got id=%v, size=%v (%v)", i, len(v), id, size, v)
+			}
+		}
+	})
+	t.Run("open as 32-bit layout", func(t *testing.T) {
+		if _, err := Open(fp.Name()); err == nil {
+			t.Errorf("expected invalid header error")
+		}
+	})
+}
+
+func TestIsUint64(t *testing.T) {
+	b := NewDoubleArrayBuilder(nil)
+	if err := b.Build([]string{"a", "b", "c"}, nil); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := b.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if ok, err := isUint64(&buf); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	} else if ok {
+		t.Errorf("expected the 32-bit layout")
+	}
+}
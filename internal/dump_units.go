@@ -0,0 +1,147 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// unitDumper decodes the units of a double array for debugging.
+type unitDumper struct {
+	unitWalker
+	// raw returns the encoded unit of the layout.
+	raw func(i uint64) (uint64, error)
+	// is32 is true if the layout is 32-bit, which has the extension bit of the offset.
+	is32 bool
+}
+
+// dumpUnits writes the units decoded one per line, with the kind, the fields, the prefix of the node and the anomalies,
+// and the summary at the end. The units unreachable from the root are the fillers inserted by fixing blocks,
+// or the anomalies if they are not empty. The prefix of the unit shared by the multiple parents is of the parent reached first.
+func dumpUnits(w io.Writer, at unitAccessor, size uint64, raw func(i uint64) (uint64, error), is32 bool) error {
+	d := unitDumper{
+		unitWalker: newUnitWalker(at, size),
+		raw:        raw,
+		is32:       is32,
+	}
+	d.parents = make([]uint64, size)
+	if err := d.traverse(); err != nil {
+		return err
+	}
+	return d.write(w)
+}
+
+// prefix returns the key of the path from the root to the node.
+func (d *unitDumper) prefix(pos uint64) (string, error) {
+	var key []byte
+	for pos != 0 && len(key) <= int(d.size) {
+		u, err := d.at(pos)
+		if err != nil {
+			return "", err
+		}
+		key = append(key, byte(u.label()))
+		pos = d.parents[pos]
+	}
+	for i, j := 0, len(key)-1; i < j; i, j = i+1, j-1 {
+		key[i], key[j] = key[j], key[i]
+	}
+	return string(key), nil
+}
+
+func (d *unitDumper) write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	var numNodes, numLeaves, numFillers int
+	for i := uint64(0); i < d.size; i++ {
+		u, err := d.at(i)
+		if err != nil {
+			return err
+		}
+		raw, err := d.raw(i)
+		if err != nil {
+			return err
+		}
+		encoded := fmt.Sprintf("0x%016X", raw)
+		if d.is32 {
+			encoded = fmt.Sprintf("0x%08X", raw)
+		}
+		var kind, fields string
+		switch d.kinds[i] {
+		case unitNode:
+			numNodes++
+			prefix, err := d.prefix(i)
+			if err != nil {
+				return err
+			}
+			kind = "node"
+			fields = fmt.Sprintf("label=0x%02X leaf=%v offset=%d", u.label(), u.hasLeaf(), u.offset())
+			if d.is32 && raw&(1<<9) != 0 {
+				fields += " ext=true"
+			}
+			fields += " prefix=" + strconv.Quote(prefix)
+		case unitLeaf:
+			numLeaves++
+			prefix, err := d.prefix(d.parents[i])
+			if err != nil {
+				return err
+			}
+			kind = "leaf"
+			fields = fmt.Sprintf("value=%d key=%s", u.value(), strconv.Quote(prefix))
+		default:
+			if u.label()&(1<<63) == 0 && !u.hasLeaf() && u.offset() == 0 {
+				numFillers++
+				kind = "filler"
+				fields = fmt.Sprintf("label=0x%02X", u.label())
+				break
+			}
+			kind = "unreachable"
+			if u.label()&(1<<63) != 0 {
+				fields = fmt.Sprintf("value=%d", u.value())
+			} else {
+				fields = fmt.Sprintf("label=0x%02X leaf=%v offset=%d", u.label(), u.hasLeaf(), u.offset())
+			}
+			d.anomaly(i, "unreachable unit which is not a filler")
+		}
+		fmt.Fprintf(bw, "%d\t%s\t%s\t%s", i, encoded, kind, fields)
+		for _, a := range d.anomalies[i] {
+			fmt.Fprintf(bw, "\t! %s", a)
+		}
+		fmt.Fprintln(bw)
+	}
+	var numAnomalies int
+	for _, a := range d.anomalies {
+		numAnomalies += len(a)
+	}
+	fmt.Fprintf(bw, "# units %d, nodes %d, leaves %d, fillers %d, anomalies %d\n", d.size, numNodes, numLeaves, numFillers, numAnomalies)
+	return bw.Flush()
+}
+
+// DumpUnits writes the units decoded one per line for debugging.
+func (a DoubleArrayUint32) DumpUnits(w io.Writer) error {
+	return dumpUnits(w, a.at64, uint64(len(a.array)), func(i uint64) (uint64, error) {
+		u, err := a.at(uint32(i))
+		return uint64(u), err
+	}, true)
+}
+
+// DumpUnits writes the units decoded one per line for debugging.
+func (a DoubleArrayUint64) DumpUnits(w io.Writer) error {
+	return dumpUnits(w, a.at, uint64(len(a.array)), func(i uint64) (uint64, error) {
+		u, err := a.at(i)
+		return uint64(u), err
+	}, false)
+}
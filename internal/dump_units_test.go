@@ -0,0 +1,156 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDumpUnits(t *testing.T) {
+	keys := []string{"a", "ab", "b", "電気"}
+	b := NewDoubleArrayBuilder(nil)
+	if err := b.Build(keys, []uint32{1, 2, 3, 4}); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	a32, err := b.DoubleArrayUint32()
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	a64, err := BuildDoubleArrayUint64(keys, []uint32{1, 2, 3, 4}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	for name, a := range map[string]interface {
+		DumpUnits(w io.Writer) error
+	}{"32-bit": a32, "64-bit": a64} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := a.DumpUnits(&buf); err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			got := buf.String()
+			for _, expected := range []string{
+				"\tnode\tlabel=0x61 leaf=true offset=",
+				"\tleaf\tvalue=2 key=\"ab\"\n",
+				"\tleaf\tvalue=4 key=\"電気\"\n",
+				"\tfiller\tlabel=0x",
+				fmt.Sprintf("# units %d, nodes 10, leaves 4, fillers %d, anomalies 0\n", b.Stats().NumUnits, b.Stats().NumFillerUnits),
+			} {
+				if !strings.Contains(got, expected) {
+					t.Errorf("expected %q in %v", expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDumpUnits_Anomalies(t *testing.T) {
+	build := func() DoubleArrayUint32 {
+		a, err := BuildDoubleArray([]string{"a", "b"}, []uint32{1, 2}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		return *a
+	}
+	// node returns the index of the node of the label under the root.
+	node := func(a DoubleArrayUint32, label byte) int {
+		return int(unit(a.array[0]).offset() ^ uint32(label))
+	}
+	testCases := []struct {
+		name     string
+		modify   func(a DoubleArrayUint32)
+		expected string
+	}{
+		{
+			name: "offset out of range",
+			modify: func(a DoubleArrayUint32) {
+				u := unit(a.array[node(a, 'a')])
+				u.setOffset(uint32(len(a.array)) * 2)
+				a.array[node(a, 'a')] = uint32(u)
+			},
+			expected: "! offset out of range",
+		},
+		{
+			name: "invalid leaf",
+			modify: func(a DoubleArrayUint32) {
+				i := node(a, 'a')
+				leaf := i ^ int(unit(a.array[i]).offset())
+				a.array[leaf] = 0
+			},
+			expected: "! invalid leaf at",
+		},
+		{
+			name: "dead end",
+			modify: func(a DoubleArrayUint32) {
+				u := unit(a.array[node(a, 'a')])
+				u.setHasLeaf(false)
+				a.array[node(a, 'a')] = uint32(u)
+			},
+			expected: "! dead end",
+		},
+		{
+			name: "unreachable",
+			modify: func(a DoubleArrayUint32) {
+				for i := len(a.array) - 1; ; i-- {
+					if unit(a.array[i]).hasLeaf() || unit(a.array[i]).label()&(1<<31) != 0 {
+						continue
+					}
+					u := unit(a.array[i])
+					u.setValue(5)
+					a.array[i] = uint32(u)
+					return
+				}
+			},
+			expected: "unreachable\tvalue=5\t! unreachable unit which is not a filler",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := build()
+			tc.modify(a)
+			var buf bytes.Buffer
+			if err := a.DumpUnits(&buf); err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			got := buf.String()
+			if !strings.Contains(got, tc.expected) {
+				t.Errorf("expected %q in %v", tc.expected, got)
+			}
+			if strings.Contains(got, "anomalies 0\n") {
+				t.Errorf("expected anomalies in the summary, %v", got[strings.LastIndex(got, "#"):])
+			}
+		})
+	}
+}
+
+func TestDumpUnits_Shared(t *testing.T) {
+	// the units of the common suffixes are shared by the multiple parents.
+	keys, values, _ := conformanceKeys()
+	a, err := BuildDoubleArray(keys, values, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	var buf bytes.Buffer
+	if err := a.DumpUnits(&buf); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if got := buf.String(); !strings.HasSuffix(got, "anomalies 0\n") {
+		t.Errorf("expected no anomalies, got %v", got[strings.LastIndex(got, "#"):])
+	}
+}
@@ -0,0 +1,408 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// freeUnit is the content of the unused units of the dynamic double array.
+// It has the value flag, so it never matches a label.
+const freeUnit = unit64(1 << 63)
+
+// DynamicDoubleArray represents the TRIE data structure which supports the insertions, the deletions and the updates of the keys.
+// The units are arranged in the 64-bit layout and managed by the free list of the extra units like the builder,
+// but the list covers all blocks, so the units released by the deletions are reused.
+// It is safe to search concurrently with the modifications.
+type DynamicDoubleArray struct {
+	// mu guards the units and the extras against the modifications while searching.
+	mu         sync.RWMutex
+	units      []unit64
+	extras     []extraUnit
+	extrasHead int
+	numKeys    int
+}
+
+// NewDynamicDoubleArray returns the empty dynamic double array.
+func NewDynamicDoubleArray() *DynamicDoubleArray {
+	d := &DynamicDoubleArray{
+		extrasHead: -1,
+	}
+	d.reserveID(0)
+	d.units[0] = 0
+	return d
+}
+
+// Len returns the number of the keys.
+func (d *DynamicDoubleArray) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.numKeys
+}
+
+// Insert adds the key with the value.
+// It returns ErrKeyExists if the key is already in the TRIE, and an error for the zero-length key like the builders.
+func (d *DynamicDoubleArray) Insert(key string, value uint32) error {
+	if len(key) == 0 {
+		return fmt.Errorf("zero-length key")
+	}
+	if strings.IndexByte(key, 0) >= 0 {
+		return fmt.Errorf("invalid null character, %v", key)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pos := 0
+	for i := 0; i < len(key); i++ {
+		next := d.child(pos, key[i])
+		if next < 0 {
+			var err error
+			if next, err = d.addChild(pos, key[i]); err != nil {
+				return err
+			}
+			d.units[next] = 0
+			d.units[next].setLabel(key[i])
+		}
+		pos = next
+	}
+	if d.units[pos].hasLeaf() {
+		return ErrKeyExists
+	}
+	leaf, err := d.addChild(pos, 0)
+	if err != nil {
+		return err
+	}
+	d.units[leaf].setValue(value)
+	d.units[pos].setHasLeaf(true)
+	d.numKeys++
+	return nil
+}
+
+// Update sets the value of the key.
+// It returns ErrKeyNotFound if the key is not in the TRIE.
+func (d *DynamicDoubleArray) Update(key string, value uint32) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	path := d.path(key)
+	if path == nil {
+		return ErrKeyNotFound
+	}
+	leaf := d.child(path[len(path)-1], 0)
+	d.units[leaf].setValue(value)
+	return nil
+}
+
+// Delete removes the key, and the nodes which are not the prefixes of the other keys.
+// It returns ErrKeyNotFound if the key is not in the TRIE.
+func (d *DynamicDoubleArray) Delete(key string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	path := d.path(key)
+	if path == nil {
+		return ErrKeyNotFound
+	}
+	d.removeChild(path[len(key)], 0)
+	for i := len(key) - 1; i >= 0; i-- {
+		if d.units[path[i+1]].offset() != 0 {
+			break
+		}
+		d.removeChild(path[i], key[i])
+	}
+	d.numKeys--
+	return nil
+}
+
+// path returns the positions of the nodes from the root to the key, or nil if the key is not in the TRIE.
+func (d *DynamicDoubleArray) path(key string) []int {
+	ret := make([]int, 1, len(key)+1)
+	pos := 0
+	for i := 0; i < len(key); i++ {
+		if pos = d.child(pos, key[i]); pos < 0 {
+			return nil
+		}
+		ret = append(ret, pos)
+	}
+	if !d.units[pos].hasLeaf() {
+		return nil
+	}
+	return ret
+}
+
+// child returns the position of the child of the node with the label, or -1 if not found.
+// The child with the label 0 is the unit of the value.
+func (d *DynamicDoubleArray) child(pos int, label byte) int {
+	u := d.units[pos]
+	if u.offset() == 0 {
+		return -1
+	}
+	id := pos ^ int(u.offset()) ^ int(label)
+	if label == 0 {
+		if !u.hasLeaf() {
+			return -1
+		}
+		return id
+	}
+	if id >= len(d.units) || d.units[id].label() != uint64(label) {
+		return -1
+	}
+	return id
+}
+
+// labels returns the labels of the children of the node.
+func (d *DynamicDoubleArray) labels(pos int) []byte {
+	u := d.units[pos]
+	if u.offset() == 0 {
+		return nil
+	}
+	var ret []byte
+	if u.hasLeaf() {
+		ret = append(ret, 0)
+	}
+	offset := pos ^ int(u.offset())
+	for label := 1; label <= 0xFF; label++ {
+		if id := offset ^ label; id < len(d.units) && d.units[id].label() == uint64(label) {
+			ret = append(ret, byte(label))
+		}
+	}
+	return ret
+}
+
+// addChild reserves the unit of the child of the node with the label and returns the position of it.
+// If the unit is used by another node, the children of the node are moved to the valid offset.
+// The offset 0 of a node means that the node has no children, the value of a node is never at the node itself.
+func (d *DynamicDoubleArray) addChild(pos int, label byte) (int, error) {
+	u := d.units[pos]
+	if u.offset() == 0 {
+		offset := d.findValidOffset(pos, []byte{label}, nil)
+		d.reserveID(offset ^ int(label))
+		d.extras[offset].isUsed = true
+		if err := d.units[pos].setOffset(uint64(pos ^ offset)); err != nil {
+			return -1, err
+		}
+		return offset ^ int(label), nil
+	}
+	offset := pos ^ int(u.offset())
+	if id := offset ^ int(label); id >= len(d.units) || !d.extras[id].isFixed {
+		d.reserveID(id)
+		return id, nil
+	}
+	labels := append(d.labels(pos), label)
+	bases := make([]int, len(labels))
+	for i, l := range labels {
+		bases[i] = -1
+		if child := d.units[offset^int(l)]; l != 0 && l != label && child.offset() != 0 {
+			bases[i] = offset ^ int(l) ^ int(child.offset())
+		}
+	}
+	newOffset := d.findValidOffset(pos, labels, bases)
+	for _, l := range labels[:len(labels)-1] {
+		if err := d.move(offset^int(l), newOffset^int(l)); err != nil {
+			return -1, err
+		}
+	}
+	d.reserveID(newOffset ^ int(label))
+	d.extras[offset].isUsed = false
+	d.extras[newOffset].isUsed = true
+	if err := d.units[pos].setOffset(uint64(pos ^ newOffset)); err != nil {
+		return -1, err
+	}
+	return newOffset ^ int(label), nil
+}
+
+// removeChild releases the unit of the child of the node with the label.
+// The offset of the node is released if the node has no children.
+func (d *DynamicDoubleArray) removeChild(pos int, label byte) {
+	offset := pos ^ int(d.units[pos].offset())
+	d.free(offset ^ int(label))
+	if label == 0 {
+		d.units[pos].setHasLeaf(false)
+	}
+	if len(d.labels(pos)) == 0 {
+		d.extras[offset].isUsed = false
+		d.units[pos].setOffset(0)
+	}
+}
+
+// move moves the unit, the offset of the unit is relative to the position, so it is recalculated.
+func (d *DynamicDoubleArray) move(from, to int) error {
+	u := d.units[from]
+	d.reserveID(to)
+	if uint64(u)&(1<<63) == 0 && u.offset() != 0 {
+		if err := u.setOffset(uint64(to) ^ uint64(from) ^ u.offset()); err != nil {
+			return err
+		}
+	}
+	d.units[to] = u
+	d.free(from)
+	return nil
+}
+
+// findValidOffset returns the offset of the node, where the units of all labels are unused.
+// The bases are the offsets of the children to be moved, or -1, and a child never moves to its offset,
+// where the offset relative to the child would be 0.
+// The offset in the next block is returned if no offsets in the free list are valid.
+func (d *DynamicDoubleArray) findValidOffset(id int, labels []byte, bases []int) int {
+	if d.extrasHead < 0 {
+		return len(d.units)
+	}
+	unfixedID := d.extrasHead
+	for {
+		if offset := unfixedID ^ int(labels[0]); d.isValidOffset(id, offset, labels, bases) {
+			return offset
+		}
+		unfixedID = d.extras[unfixedID].next
+		if unfixedID == d.extrasHead {
+			break
+		}
+	}
+	return len(d.units)
+}
+
+func (d *DynamicDoubleArray) isValidOffset(id, offset int, labels []byte, bases []int) bool {
+	if offset == id || d.extras[offset].isUsed {
+		return false
+	}
+	for i, l := range labels {
+		if i > 0 && d.extras[offset^int(l)].isFixed {
+			return false
+		}
+		if bases != nil && offset^int(l) == bases[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// reserveID removes the unit from the free list, and expands the units if the id is out of them.
+func (d *DynamicDoubleArray) reserveID(id int) {
+	for id >= len(d.units) {
+		d.expandUnits()
+	}
+	e := &d.extras[id]
+	if e.next == id {
+		d.extrasHead = -1
+	} else {
+		if d.extrasHead == id {
+			d.extrasHead = e.next
+		}
+		d.extras[e.prev].next = e.next
+		d.extras[e.next].prev = e.prev
+	}
+	e.isFixed = true
+}
+
+// free clears the unit and adds it to the free list.
+func (d *DynamicDoubleArray) free(id int) {
+	d.units[id] = freeUnit
+	e := &d.extras[id]
+	e.isFixed = false
+	if d.extrasHead < 0 {
+		e.prev, e.next = id, id
+		d.extrasHead = id
+		return
+	}
+	head := &d.extras[d.extrasHead]
+	e.prev, e.next = head.prev, d.extrasHead
+	d.extras[head.prev].next = id
+	head.prev = id
+}
+
+func (d *DynamicDoubleArray) expandUnits() {
+	begin := len(d.units)
+	for i := 0; i < blockSize; i++ {
+		d.units = append(d.units, freeUnit)
+		d.extras = append(d.extras, extraUnit{})
+	}
+	for id := begin; id < len(d.units); id++ {
+		d.free(id)
+	}
+}
+
+func (d *DynamicDoubleArray) at(i uint64) (unit64, error) {
+	if i >= uint64(len(d.units)) {
+		return 0, fmt.Errorf("index out of bounds")
+	}
+	return d.units[i], nil
+}
+
+// lockedAt is at guarded against the modifications for the iterators.
+func (d *DynamicDoubleArray) lockedAt(i uint64) (unit64, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.at(i)
+}
+
+// ExactMatchSearch searches TRIE by a given keyword and returns the id and it's length if found.
+func (d *DynamicDoubleArray) ExactMatchSearch(key string) (id, size int, err error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return exactMatchSearch(units[unit64]{words: d.units}, key)
+}
+
+// CommonPrefixSearch finds keywords sharing common prefix in an input and returns the array of pairs (id and it's length) if found.
+func (d *DynamicDoubleArray) CommonPrefixSearch(key string, offset int) ([][2]int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return commonPrefixSearch(units[unit64]{words: d.units}, key, offset)
+}
+
+// CommonPrefixSearchCallback finds keywords sharing common prefix in an input and callback with id and it's length.
+// The callback is called after the search releases the lock, so it may search or modify the TRIE.
+func (d *DynamicDoubleArray) CommonPrefixSearchCallback(key string, offset int, callback func(id, size int)) error {
+	ret, err := d.CommonPrefixSearch(key, offset)
+	if err != nil {
+		return err
+	}
+	for _, v := range ret {
+		callback(v[0], v[1])
+	}
+	return nil
+}
+
+// PredictiveSearchCallback finds keywords starting with the prefix and callback with the keyword and it's id in the order of the keywords.
+func (d *DynamicDoubleArray) PredictiveSearchCallback(prefix string, callback func(key string, id int)) error {
+	return predictiveSearch(d.Keys(prefix), callback)
+}
+
+// Keys returns the iterator of the keywords starting with the prefix.
+// The keys modified while iterating may be or may not be iterated.
+func (d *DynamicDoubleArray) Keys(prefix string) *KeyIterator {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return newKeyIterator(d.lockedAt, uint64(len(d.units)), prefix)
+}
+
+// Compact builds the static double array of the keys and the values.
+// The builder arranges it in the 64-bit layout if the 32-bit layout cannot hold it, and writes it by WriteTo.
+func (d *DynamicDoubleArray) Compact(progress ProgressFunction) (*DoubleArrayBuilder, error) {
+	var keys []string
+	var values []uint32
+	d.mu.RLock()
+	it := newKeyIterator(d.at, uint64(len(d.units)), "")
+	for it.Next() {
+		keys = append(keys, it.Key())
+		values = append(values, uint32(it.ID()))
+	}
+	d.mu.RUnlock()
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	b := NewDoubleArrayBuilder(progress)
+	if err := b.Build(keys, values); err != nil {
+		return nil, fmt.Errorf("build error, %v", err)
+	}
+	return b, nil
+}
@@ -0,0 +1,266 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func init() {
+	searcherFactories["dynamic"] = func(t *testing.T, keys []string, values []uint32) (searcher, func()) {
+		d := NewDynamicDoubleArray()
+		// inserts in the random order to move the nodes.
+		for _, i := range rand.New(rand.NewSource(1)).Perm(len(keys)) {
+			if err := d.Insert(keys[i], values[i]); err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+		}
+		return d, func() {}
+	}
+}
+
+func TestDynamicDoubleArray(t *testing.T) {
+	d := NewDynamicDoubleArray()
+	t.Run("insert", func(t *testing.T) {
+		for i, v := range []string{"abc", "ab", "b", "電気通信", "電気"} {
+			if err := d.Insert(v, uint32(i)); err != nil {
+				t.Errorf("unexpected error, %v", err)
+			}
+		}
+		if err := d.Insert("ab", 100); err != ErrKeyExists {
+			t.Errorf("expected ErrKeyExists, got %v", err)
+		}
+		if err := d.Insert("a\x00b", 100); err == nil {
+			t.Errorf("expected invalid null character error")
+		}
+		if got, expected := d.Len(), 5; got != expected {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+		if id, size, err := d.ExactMatchSearch("ab"); err != nil || id != 1 || size != 2 {
+			t.Errorf("expected id=1, size=2, got id=%v, size=%v, err=%v", id, size, err)
+		}
+		if id, _, err := d.ExactMatchSearch("a"); err != nil || id != -1 {
+			t.Errorf("expected not found, got id=%v, err=%v", id, err)
+		}
+	})
+	t.Run("update", func(t *testing.T) {
+		maxValue := uint32(1<<32 - 1)
+		if err := d.Update("ab", maxValue); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if id, _, err := d.ExactMatchSearch("ab"); err != nil || id != int(maxValue) {
+			t.Errorf("expected id=%v, got id=%v, err=%v", maxValue, id, err)
+		}
+		if err := d.Update("a", 1); err != ErrKeyNotFound {
+			t.Errorf("expected ErrKeyNotFound, got %v", err)
+		}
+	})
+	t.Run("delete", func(t *testing.T) {
+		if err := d.Delete("ab"); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if err := d.Delete("ab"); err != ErrKeyNotFound {
+			t.Errorf("expected ErrKeyNotFound, got %v", err)
+		}
+		if err := d.Delete("電"); err != ErrKeyNotFound {
+			t.Errorf("expected ErrKeyNotFound, got %v", err)
+		}
+		ret, err := d.CommonPrefixSearch("abc", 0)
+		if err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if expected := [][2]int{{0, 3}}; !reflect.DeepEqual(expected, ret) {
+			t.Errorf("expected %v, got %v", expected, ret)
+		}
+	})
+	t.Run("delete all", func(t *testing.T) {
+		for _, v := range []string{"abc", "b", "電気通信", "電気"} {
+			if err := d.Delete(v); err != nil {
+				t.Errorf("unexpected error, %v", err)
+			}
+		}
+		if got := d.Len(); got != 0 {
+			t.Errorf("expected no keys, got %v", got)
+		}
+		for id := 1; id < len(d.units); id++ {
+			if d.extras[id].isFixed || d.extras[id].isUsed {
+				t.Errorf("expected all units are released, unit %v is used", id)
+			}
+		}
+		if it := d.Keys(""); it.Next() {
+			t.Errorf("unexpected key, %q", it.Key())
+		}
+	})
+}
+
+func TestDynamicDoubleArray_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	d := NewDynamicDoubleArray()
+	expected := map[string]uint32{}
+	randomKey := func() string {
+		b := make([]byte, 1+r.Intn(5))
+		for i := range b {
+			b[i] = "abcd\xff"[r.Intn(5)]
+		}
+		return string(b)
+	}
+	for i := 0; i < 20000; i++ {
+		key, value := randomKey(), r.Uint32()
+		_, ok := expected[key]
+		switch r.Intn(3) {
+		case 0:
+			if err := d.Insert(key, value); ok && err != ErrKeyExists || !ok && err != nil {
+				t.Fatalf("insert %q, unexpected error, %v", key, err)
+			}
+			if !ok {
+				expected[key] = value
+			}
+		case 1:
+			if err := d.Update(key, value); ok && err != nil || !ok && err != ErrKeyNotFound {
+				t.Fatalf("update %q, unexpected error, %v", key, err)
+			}
+			if ok {
+				expected[key] = value
+			}
+		case 2:
+			if err := d.Delete(key); ok && err != nil || !ok && err != ErrKeyNotFound {
+				t.Fatalf("delete %q, unexpected error, %v", key, err)
+			}
+			delete(expected, key)
+		}
+	}
+	if got := d.Len(); got != len(expected) {
+		t.Errorf("expected %v keys, got %v", len(expected), got)
+	}
+	var keys []string
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var got []string
+	if err := d.PredictiveSearchCallback("", func(key string, id int) {
+		if uint32(id) != expected[key] {
+			t.Errorf("expected id=%v, got %v (%q)", expected[key], id, key)
+		}
+		got = append(got, key)
+	}); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if !reflect.DeepEqual(keys, got) {
+		t.Errorf("expected %v keys, got %v keys", len(keys), len(got))
+	}
+	for i := 0; i < 1000; i++ {
+		key := randomKey()
+		v, ok := expected[key]
+		if id, _, err := d.ExactMatchSearch(key); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		} else if ok && uint32(id) != v || !ok && id != -1 {
+			t.Errorf("expected %v (%v), got %v (%q)", v, ok, id, key)
+		}
+	}
+
+	t.Run("compact", func(t *testing.T) {
+		b, err := d.Compact(nil)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if !b.IsUint64() {
+			t.Errorf("expected the 64-bit layout for 32-bit values")
+		}
+		a, err := b.DoubleArrayUint64()
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		for _, k := range keys {
+			if id, _, err := a.ExactMatchSearch(k); err != nil || uint32(id) != expected[k] {
+				t.Errorf("expected id=%v, got id=%v, err=%v (%q)", expected[k], id, err, k)
+			}
+		}
+	})
+}
+
+func TestDynamicDoubleArray_InsertEmptyKey(t *testing.T) {
+	d := NewDynamicDoubleArray()
+	if err := d.Insert("", 0); err == nil {
+		t.Errorf("expected error")
+	}
+	if err := d.Insert("a", 1); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if got := d.Len(); got != 1 {
+		t.Errorf("expected 1 key, got %v", got)
+	}
+	if _, err := d.Compact(nil); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+}
+
+func TestDynamicDoubleArray_ModifyInCallback(t *testing.T) {
+	d := NewDynamicDoubleArray()
+	for i, key := range []string{"a", "ab", "abc"} {
+		if err := d.Insert(key, uint32(i)); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+	}
+	var got [][2]int
+	if err := d.CommonPrefixSearchCallback("abc", 0, func(id, size int) {
+		got = append(got, [2]int{id, size})
+		if err := d.Update("abc", uint32(len(got)+10)); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+	}); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if expected := [][2]int{{0, 1}, {1, 2}, {2, 3}}; !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if id, _, err := d.ExactMatchSearch("abc"); err != nil || id != 13 {
+		t.Errorf("expected id=13, got id=%v, err=%v", id, err)
+	}
+}
+
+func TestDynamicDoubleArray_Concurrently(t *testing.T) {
+	d := NewDynamicDoubleArray()
+	keys := []string{"a", "ab", "abc", "b", "bc", "c"}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if _, _, err := d.ExactMatchSearch("abc"); err != nil {
+					t.Errorf("unexpected error, %v", err)
+				}
+				it := d.Keys("a")
+				for it.Next() {
+				}
+				it.Close()
+			}
+		}()
+	}
+	for j := 0; j < 1000; j++ {
+		key := keys[j%len(keys)]
+		if err := d.Insert(key, uint32(j)); err == ErrKeyExists {
+			d.Delete(key)
+		} else if err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,42 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrClosed is the error returned by the searches of the closed TRIE.
+var ErrClosed = errors.New("dartsclone: TRIE already closed")
+
+// ErrKeyExists is the error returned by the insertion of the key which is already in the TRIE.
+var ErrKeyExists = errors.New("dartsclone: key already exists")
+
+// ErrKeyNotFound is the error returned by the modification of the key which is not in the TRIE.
+var ErrKeyNotFound = errors.New("dartsclone: key not found")
+
+// errOutOfBounds is the error of the index out of the units, which means the broken double array.
+var errOutOfBounds = errors.New("index out of bounds")
+
+// MmapError is the error of the system call mapping the file on the memory.
+// The errors of the advices and the lock of the mapped memory are not MmapError.
+type MmapError struct {
+	Err error
+}
+
+func (e *MmapError) Error() string {
+	return fmt.Sprintf("mmap error, %v", e.Err)
+}
@@ -0,0 +1,224 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+)
+
+// KeyIterator iterates the keys of the double array and their ids in the order of the keys.
+//
+//	it := a.Keys("")
+//	for it.Next() {
+//		fmt.Println(it.Key(), it.ID())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type KeyIterator struct {
+	at      unitAccessor
+	size    uint64
+	prefix  string
+	stack   []keyIteratorFrame
+	key     []byte
+	id      int
+	init    bool
+	err     error
+	release func()
+	// nextFunc returns the keys instead of the double array if not nil.
+	nextFunc func() (key string, id int, ok bool, err error)
+}
+
+type keyIteratorFrame struct {
+	offset  uint64
+	hasLeaf bool
+	// next is the label of the child to visit next, 0 is the leaf.
+	next uint64
+}
+
+// newKeyIterator returns an iterator of the keys which start with the prefix.
+// The units of the 32-bit layout are accessed as the units of the 64-bit layout.
+func newKeyIterator(at unitAccessor, size uint64, prefix string) *KeyIterator {
+	return &KeyIterator{
+		at:     at,
+		size:   size,
+		prefix: prefix,
+	}
+}
+
+// Next advances the iterator to the next key.
+// It returns false when no keys are left or an error occurred.
+func (it *KeyIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if !it.next() {
+		it.Close()
+		return false
+	}
+	return true
+}
+
+func (it *KeyIterator) next() bool {
+	if it.nextFunc != nil {
+		key, id, ok, err := it.nextFunc()
+		if err != nil {
+			it.err = err
+			return false
+		}
+		if !ok {
+			return false
+		}
+		it.key = append(it.key[:0], key...)
+		it.id = id
+		return true
+	}
+	if !it.init {
+		it.init = true
+		if err := it.seek(); err != nil {
+			it.err = err
+			return false
+		}
+	}
+	for len(it.stack) > 0 {
+		f := &it.stack[len(it.stack)-1]
+		if f.next > 0xFF {
+			it.stack = it.stack[:len(it.stack)-1]
+			if len(it.stack) > 0 {
+				it.key = it.key[:len(it.key)-1]
+			}
+			continue
+		}
+		label := f.next
+		f.next++
+		if label == 0 {
+			if !f.hasLeaf {
+				continue
+			}
+			u, err := it.at(f.offset)
+			if err == ErrClosed {
+				it.err = err
+				return false
+			} else if err != nil {
+				it.err = fmt.Errorf("invalid leaf, %v", err)
+				return false
+			}
+			it.id = int(u.value())
+			return true
+		}
+		pos := f.offset ^ label
+		u, err := it.at(pos)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		if u.label() != label {
+			continue
+		}
+		if uint64(len(it.stack)) > it.size {
+			it.err = fmt.Errorf("broken array, cyclic path at %v", pos)
+			return false
+		}
+		it.key = append(it.key, byte(label))
+		it.push(pos, u)
+	}
+	return false
+}
+
+func (it *KeyIterator) seek() error {
+	pos := uint64(0)
+	u, err := it.at(pos)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(it.prefix); i++ {
+		pos ^= u.offset() ^ uint64(it.prefix[i])
+		u, err = it.at(pos)
+		if err != nil {
+			return err
+		}
+		if u.label() != uint64(it.prefix[i]) {
+			return nil
+		}
+	}
+	it.key = append(it.key, it.prefix...)
+	it.push(pos, u)
+	return nil
+}
+
+func (it *KeyIterator) push(pos uint64, u unit64) {
+	it.stack = append(it.stack, keyIteratorFrame{
+		offset:  pos ^ u.offset(),
+		hasLeaf: u.hasLeaf(),
+	})
+}
+
+// Close stops the iteration and releases the TRIE held by the iterator.
+// It is called automatically when Next returns false.
+func (it *KeyIterator) Close() {
+	it.init = true
+	it.stack = nil
+	it.nextFunc = nil
+	if it.release != nil {
+		it.release()
+		it.release = nil
+	}
+}
+
+// SetKeyIteratorRelease adds the function called once when the iteration stops.
+func SetKeyIteratorRelease(it *KeyIterator, release func()) {
+	if prev := it.release; prev != nil {
+		it.release = func() {
+			prev()
+			release()
+		}
+		return
+	}
+	it.release = release
+}
+
+// NewKeyIteratorFunc returns the iterator of the keys returned by next in order.
+// The next returns false when no keys are left.
+func NewKeyIteratorFunc(next func() (key string, id int, ok bool, err error)) *KeyIterator {
+	return &KeyIterator{nextFunc: next}
+}
+
+// NewErrKeyIterator returns the iterator which iterates nothing and returns the error by Err.
+func NewErrKeyIterator(err error) *KeyIterator {
+	return &KeyIterator{init: true, err: err}
+}
+
+// Key returns the current key.
+func (it KeyIterator) Key() string {
+	return string(it.key)
+}
+
+// ID returns the id of the current key.
+func (it KeyIterator) ID() int {
+	return it.id
+}
+
+// Err returns the error occurred while iterating.
+func (it KeyIterator) Err() error {
+	return it.err
+}
+
+// predictiveSearch calls the callback with the keys which start with the prefix and their ids in the order of the keys.
+func predictiveSearch(it *KeyIterator, callback func(key string, id int)) error {
+	for it.Next() {
+		callback(it.Key(), it.ID())
+	}
+	return it.Err()
+}
@@ -0,0 +1,192 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeyIterator(t *testing.T) {
+	keys := []string{
+		"a",
+		"ab",
+		"abc",
+		"b",
+		"bc",
+		"電気",
+		"電気通信",
+	}
+	values := []uint32{'b', 'c', 'a', 'c', 'b', 0xE9, 0xE4}
+	a, err := BuildDoubleArray(keys, values, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	a64, err := BuildDoubleArrayUint64(keys, values, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	testCases := []struct {
+		prefix string
+		keys   []string
+		ids    []int
+	}{
+		{prefix: "", keys: keys, ids: []int{'b', 'c', 'a', 'c', 'b', 0xE9, 0xE4}},
+		{prefix: "ab", keys: []string{"ab", "abc"}, ids: []int{'c', 'a'}},
+		{prefix: "電", keys: []string{"電気", "電気通信"}, ids: []int{0xE9, 0xE4}},
+		{prefix: "x"},
+		{prefix: "abcd"},
+	}
+	for _, it := range []struct {
+		name string
+		keys func(prefix string) *KeyIterator
+	}{
+		{name: "32-bit", keys: a.Keys},
+		{name: "64-bit", keys: a64.Keys},
+	} {
+		t.Run(it.name, func(t *testing.T) {
+			for _, tc := range testCases {
+				var keys []string
+				var ids []int
+				iter := it.keys(tc.prefix)
+				for iter.Next() {
+					keys = append(keys, iter.Key())
+					ids = append(ids, iter.ID())
+				}
+				if err := iter.Err(); err != nil {
+					t.Errorf("unexpected error, %v", err)
+				}
+				if !reflect.DeepEqual(tc.keys, keys) {
+					t.Errorf("keys: expected %v, got %v (%v)", tc.keys, keys, tc.prefix)
+				}
+				if !reflect.DeepEqual(tc.ids, ids) {
+					t.Errorf("ids: expected %v, got %v (%v)", tc.ids, ids, tc.prefix)
+				}
+				if iter.Next() {
+					t.Errorf("unexpected next after the end (%v)", tc.prefix)
+				}
+			}
+		})
+	}
+	t.Run("broken array", func(t *testing.T) {
+		broken := DoubleArrayUint32{array: a.array[:len(a.array)/2]}
+		iter := broken.Keys("")
+		for iter.Next() {
+		}
+		if iter.Err() == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestUnit_Unit64(t *testing.T) {
+	var u unit
+	u.setLabel('a')
+	u.setHasLeaf(true)
+	if err := u.setOffset(1<<21 + 4); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	u64 := u.unit64()
+	if u64.label() != uint64(u.label()) || u64.hasLeaf() != u.hasLeaf() || u64.offset() != uint64(u.offset()) {
+		t.Errorf("expected %v, %v, %v, got %v, %v, %v", u.label(), u.hasLeaf(), u.offset(), u64.label(), u64.hasLeaf(), u64.offset())
+	}
+	u.setValue(1<<31 - 1)
+	if u64 := u.unit64(); uint64(u64)>>63 != 1 || u64.value() != u.value() {
+		t.Errorf("expected value %v, got %x", u.value(), uint64(u64))
+	}
+}
+
+func TestKeyIterator_Close(t *testing.T) {
+	a, err := BuildDoubleArray([]string{"a", "b", "c"}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	t.Run("release at the end", func(t *testing.T) {
+		var released int
+		it := a.Keys("")
+		SetKeyIteratorRelease(it, func() { released++ })
+		for it.Next() {
+		}
+		it.Close()
+		if released != 1 {
+			t.Errorf("expected released once, got %v", released)
+		}
+	})
+	t.Run("close while iterating", func(t *testing.T) {
+		var released int
+		it := a.Keys("")
+		SetKeyIteratorRelease(it, func() { released++ })
+		if !it.Next() {
+			t.Fatal("expected next")
+		}
+		it.Close()
+		if it.Next() {
+			t.Error("unexpected next after close")
+		}
+		if released != 1 {
+			t.Errorf("expected released once, got %v", released)
+		}
+	})
+}
+
+func TestNewKeyIteratorFunc(t *testing.T) {
+	keys := []string{"a", "b", "c"}
+	t.Run("keys", func(t *testing.T) {
+		var i int
+		it := NewKeyIteratorFunc(func() (string, int, bool, error) {
+			if i >= len(keys) {
+				return "", 0, false, nil
+			}
+			i++
+			return keys[i-1], i - 1, true, nil
+		})
+		var got []string
+		for it.Next() {
+			if it.ID() != len(got) {
+				t.Errorf("expected id=%v, got %v", len(got), it.ID())
+			}
+			got = append(got, it.Key())
+		}
+		if err := it.Err(); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if !reflect.DeepEqual(keys, got) {
+			t.Errorf("expected %v, got %v", keys, got)
+		}
+	})
+	t.Run("error", func(t *testing.T) {
+		it := NewKeyIteratorFunc(func() (string, int, bool, error) {
+			return "", 0, false, ErrClosed
+		})
+		if it.Next() {
+			t.Errorf("unexpected next")
+		}
+		if err := it.Err(); err != ErrClosed {
+			t.Errorf("expected ErrClosed, got %v", err)
+		}
+	})
+	t.Run("close", func(t *testing.T) {
+		it := NewKeyIteratorFunc(func() (string, int, bool, error) {
+			return "a", 0, true, nil
+		})
+		if !it.Next() {
+			t.Fatal("expected next")
+		}
+		it.Close()
+		if it.Next() {
+			t.Error("unexpected next after close")
+		}
+	})
+}
@@ -77,10 +77,44 @@ func (s keySet) getKeyByte(keyID, byteID int) (byte, error) {
 	return s.keys[keyID][byteID], nil
 }
 
+// keyRange represents the range of the sorted keys which start with the label.
+type keyRange struct {
+	label      byte
+	begin, end int
+}
+
+// partition splits the sorted keys into the ranges by the first byte.
+func (s keySet) partition() ([]keyRange, error) {
+	var ret []keyRange
+	for i, k := range s.keys {
+		if len(k) == 0 {
+			return nil, fmt.Errorf("zero-length key")
+		}
+		if len(ret) == 0 || ret[len(ret)-1].label != k[0] {
+			ret = append(ret, keyRange{label: k[0], begin: i})
+		}
+		ret[len(ret)-1].end = i + 1
+	}
+	return ret, nil
+}
+
 func (s keySet) hasValues() bool {
 	return len(s.values) > 0
 }
 
+// fitsUint32 returns true if the values can be stored in the units of the 32-bit layout.
+func (s keySet) fitsUint32() bool {
+	if !s.hasValues() {
+		return int64(len(s.keys)) <= 1<<31
+	}
+	for _, v := range s.values {
+		if v >= 1<<31 {
+			return false
+		}
+	}
+	return true
+}
+
 func (s keySet) getValue(id int) (uint32, error) {
 	if id < 0 {
 		return 0, fmt.Errorf("index out of bounds")
@@ -232,3 +232,33 @@ func TestKeySet_HasValues(t *testing.T) {
 		t.Errorf("expected %v, got %v", expected, got)
 	}
 }
+
+func TestKeySet_Partition(t *testing.T) {
+	t.Run("partition", func(t *testing.T) {
+		s, err := newSortedKeySet([]string{"b", "aa", "ab", "ba", "c"}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		got, err := s.partition()
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		expected := []keyRange{
+			{label: 'a', begin: 0, end: 2},
+			{label: 'b', begin: 2, end: 4},
+			{label: 'c', begin: 4, end: 5},
+		}
+		if !reflect.DeepEqual(expected, got) {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+	t.Run("zero-length key", func(t *testing.T) {
+		s, err := newSortedKeySet([]string{"", "a"}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if _, err := s.partition(); err == nil {
+			t.Errorf("expected zero-length key error")
+		}
+	})
+}
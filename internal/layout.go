@@ -0,0 +1,63 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import "fmt"
+
+// Layout represents the layout of the units in the file of the double array.
+type Layout struct {
+	// Is64 is true if the units are of the 64-bit layout.
+	Is64 bool
+	// HeaderSize is the size of the header before the units in bytes.
+	HeaderSize int64
+	// UnitSize is the size of the unit in bytes.
+	UnitSize int64
+	// BlockSize is the number of the units of the block, the units are written by the blocks.
+	BlockSize int64
+}
+
+// FileLayout returns the layout of the named file of the double array.
+func FileLayout(name string) (Layout, error) {
+	is64, err := IsUint64File(name)
+	if err != nil {
+		return Layout{}, err
+	}
+	if is64 {
+		return Layout{Is64: true, HeaderSize: int64(len(uint64Header)), UnitSize: unit64Size, BlockSize: blockSize}, nil
+	}
+	return Layout{UnitSize: unitSize, BlockSize: blockSize}, nil
+}
+
+// String returns the name of the layout.
+func (l Layout) String() string {
+	if l.Is64 {
+		return "64-bit"
+	}
+	return "32-bit"
+}
+
+// NumUnits returns the number of the units in the file of the size.
+func (l Layout) NumUnits(size int64) int64 {
+	return (size - l.HeaderSize) / l.UnitSize
+}
+
+// CheckSize checks that the file of the size consists of the header and the blocks of the units.
+func (l Layout) CheckSize(size int64) error {
+	n := size - l.HeaderSize
+	if n <= 0 || n%(l.UnitSize*l.BlockSize) != 0 {
+		return fmt.Errorf("broken array, invalid size %v", size)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestFileLayout(t *testing.T) {
+	keys := []string{"a", "ab", "abc", "b"}
+	testCases := []struct {
+		name   string
+		values []uint32
+		layout string
+	}{
+		{name: "32-bit", values: []uint32{1, 2, 3, 4}, layout: "32-bit"},
+		{name: "64-bit", values: []uint32{1, 2, 3, 1 << 31}, layout: "64-bit"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := NewDoubleArrayBuilder(nil)
+			if err := b.Build(keys, tc.values); err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			fp, err := ioutil.TempFile("", "layout")
+			if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			defer os.Remove(fp.Name())
+			size, err := b.WriteTo(fp)
+			if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			fp.Close()
+
+			l, err := FileLayout(fp.Name())
+			if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			if got := l.String(); got != tc.layout {
+				t.Errorf("expected %v, got %v", tc.layout, got)
+			}
+			if got, expected := l.NumUnits(size), int64(b.Stats().NumUnits); got != expected {
+				t.Errorf("num units: expected %v, got %v", expected, got)
+			}
+			if err := l.CheckSize(size); err != nil {
+				t.Errorf("unexpected error, %v", err)
+			}
+			if err := l.CheckSize(size - l.UnitSize); err == nil {
+				t.Errorf("expected invalid size error")
+			}
+			if err := l.CheckSize(l.HeaderSize); err == nil {
+				t.Errorf("expected invalid size error")
+			}
+		})
+	}
+}
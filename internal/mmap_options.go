@@ -0,0 +1,101 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"os"
+)
+
+// MmapOption is an option of the memory mapping.
+type MmapOption func(*mmapOptions)
+
+type mmapOptions struct {
+	random     bool
+	willNeed   bool
+	hugePage   bool
+	lock       bool
+	warmup     bool
+	background bool
+}
+
+func newMmapOptions(opts []MmapOption) mmapOptions {
+	var ret mmapOptions
+	for _, opt := range opts {
+		opt(&ret)
+	}
+	return ret
+}
+
+// MmapRandom advises that the pages are accessed randomly (MADV_RANDOM), which disables the read-ahead.
+// It is ignored on windows.
+func MmapRandom() MmapOption {
+	return func(o *mmapOptions) {
+		o.random = true
+	}
+}
+
+// MmapWillNeed advises that the pages will be accessed soon (MADV_WILLNEED), which starts reading them ahead.
+// It is ignored on windows.
+func MmapWillNeed() MmapOption {
+	return func(o *mmapOptions) {
+		o.willNeed = true
+	}
+}
+
+// MmapHugePage advises to back the pages with the transparent huge pages (MADV_HUGEPAGE).
+// It is supported on linux only.
+func MmapHugePage() MmapOption {
+	return func(o *mmapOptions) {
+		o.hugePage = true
+	}
+}
+
+// MmapLock locks the pages in the memory (mlock), so they are never paged out.
+// It is not supported on windows.
+func MmapLock() MmapOption {
+	return func(o *mmapOptions) {
+		o.lock = true
+	}
+}
+
+// MmapWarmup touches all pages after mapping, in a goroutine if background is true.
+func MmapWarmup(background bool) MmapOption {
+	return func(o *mmapOptions) {
+		o.warmup = true
+		o.background = background
+	}
+}
+
+// warmup reads a byte of each page to fault in all pages.
+// It returns the sum of the bytes read, so the reads are not optimized away.
+func warmup(b []byte) byte {
+	var sum byte
+	pageSize := os.Getpagesize()
+	for i := 0; i < len(b); i += pageSize {
+		sum += b[i]
+	}
+	return sum
+}
+
+func (o mmapOptions) warmupAfterOpen(w interface{ Warmup() error }) {
+	if !o.warmup {
+		return
+	}
+	if o.background {
+		go w.Warmup()
+		return
+	}
+	w.Warmup()
+}
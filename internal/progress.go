@@ -14,6 +14,15 @@
 
 package internal
 
+// The names of the phases of building double array.
+const (
+	PhaseSort      = "sort"
+	PhaseDAWG      = "DAWG"
+	PhaseArrange   = "arrange"
+	PhaseFixBlocks = "fix blocks"
+	PhaseWrite     = "write"
+)
+
 // ProgressFunction indicates progress bar of building double array.
 type ProgressFunction interface {
 	// SetMaximum sets the maximum of the progress bar.
@@ -21,3 +30,46 @@ type ProgressFunction interface {
 	// Increment with increase the current count on the progress bar.
 	Increment()
 }
+
+// PhaseProgressFunction indicates progress of the named phases of building double array.
+type PhaseProgressFunction interface {
+	// StartPhase starts the named phase with the total count.
+	StartPhase(name string, total int)
+	// IncrementBy increases the current count of the phase by n.
+	IncrementBy(n int)
+	// FinishPhase finishes the named phase.
+	FinishPhase(name string)
+}
+
+// progressAdapter drives a ProgressFunction as a PhaseProgressFunction.
+// Each phase restarts the progress bar with the total of the phase.
+type progressAdapter struct {
+	ProgressFunction
+}
+
+// StartPhase sets the maximum of the progress bar to the total of the phase.
+func (p progressAdapter) StartPhase(name string, total int) {
+	p.SetMaximum(total)
+}
+
+// IncrementBy increments the progress bar n times.
+func (p progressAdapter) IncrementBy(n int) {
+	for i := 0; i < n; i++ {
+		p.Increment()
+	}
+}
+
+// FinishPhase does nothing.
+func (p progressAdapter) FinishPhase(name string) {}
+
+// NewPhaseProgressFunction returns the progress function of the phases.
+// If the progress function does not implement PhaseProgressFunction, it is adapted.
+func NewPhaseProgressFunction(progress ProgressFunction) PhaseProgressFunction {
+	if progress == nil {
+		return nil
+	}
+	if p, ok := progress.(PhaseProgressFunction); ok {
+		return p
+	}
+	return progressAdapter{ProgressFunction: progress}
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+type phaseRecorder struct {
+	phases []string
+	totals map[string]int
+	counts map[string]int
+	phase  string
+}
+
+func newPhaseRecorder() *phaseRecorder {
+	return &phaseRecorder{
+		totals: map[string]int{},
+		counts: map[string]int{},
+	}
+}
+
+func (p *phaseRecorder) SetMaximum(int) {}
+func (p *phaseRecorder) Increment()     {}
+
+func (p *phaseRecorder) StartPhase(name string, total int) {
+	p.phases = append(p.phases, name)
+	p.totals[name] = total
+	p.phase = name
+}
+
+func (p *phaseRecorder) IncrementBy(n int) {
+	p.counts[p.phase] += n
+}
+
+func (p *phaseRecorder) FinishPhase(name string) {
+	p.phase = ""
+}
+
+type legacyProgress struct {
+	maximums   []int
+	increments []int
+}
+
+func (p *legacyProgress) SetMaximum(max int) {
+	p.maximums = append(p.maximums, max)
+	p.increments = append(p.increments, 0)
+}
+
+func (p *legacyProgress) Increment() {
+	p.increments[len(p.increments)-1]++
+}
+
+func TestDoubleArrayBuilder_PhaseProgress(t *testing.T) {
+	keys := []string{"a", "aa", "ab", "b", "ba", "bb", "c"}
+	values := []uint32{1, 2, 3, 4, 5, 6, 7}
+
+	t.Run("with values", func(t *testing.T) {
+		p := newPhaseRecorder()
+		b := NewDoubleArrayBuilder(p)
+		if err := b.Build(keys, values); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if _, err := b.WriteTo(&bytes.Buffer{}); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		want := []string{PhaseSort, PhaseDAWG, PhaseArrange, PhaseFixBlocks, PhaseWrite}
+		if !reflect.DeepEqual(p.phases, want) {
+			t.Errorf("expected %v, got %v", want, p.phases)
+		}
+		for _, name := range want {
+			if got, expected := p.counts[name], p.totals[name]; got != expected {
+				t.Errorf("%v: expected %v, got %v", name, expected, got)
+			}
+		}
+	})
+
+	t.Run("without values", func(t *testing.T) {
+		p := newPhaseRecorder()
+		b := NewDoubleArrayBuilder(p)
+		if err := b.Build(keys, nil); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		want := []string{PhaseSort, PhaseArrange, PhaseFixBlocks}
+		if !reflect.DeepEqual(p.phases, want) {
+			t.Errorf("expected %v, got %v", want, p.phases)
+		}
+		if got, expected := p.counts[PhaseArrange], len(keys); got != expected {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("parallel", func(t *testing.T) {
+		p := newPhaseRecorder()
+		b := NewDoubleArrayBuilder(p)
+		if err := b.BuildParallel(keys, values, 2); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		want := []string{PhaseSort, PhaseArrange, PhaseFixBlocks}
+		if !reflect.DeepEqual(p.phases, want) {
+			t.Errorf("expected %v, got %v", want, p.phases)
+		}
+		if got, expected := p.counts[PhaseArrange], len(keys); got != expected {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	})
+
+	t.Run("legacy progress function", func(t *testing.T) {
+		for _, keys := range [][]string{keys, {"ab", "abc", "b", "bc", "c", "cc", "d"}} {
+			p := &legacyProgress{}
+			b := NewDoubleArrayBuilder(p)
+			if err := b.Build(keys, values); err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			if got, expected := len(p.maximums), 4; got != expected {
+				t.Errorf("expected %v phases, got %v", expected, got)
+			}
+			// each phase reaches the maximum exactly.
+			if !reflect.DeepEqual(p.maximums, p.increments) {
+				t.Errorf("%v: maximums %v, increments %v", keys, p.maximums, p.increments)
+			}
+		}
+	})
+}
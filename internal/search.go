@@ -0,0 +1,145 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"encoding/binary"
+	"unsafe"
+)
+
+// unitAccessor returns the unit at the index of the double array.
+// The units of the 32-bit layout are accessed as the units of the 64-bit layout,
+// so the walks over the units, e.g. the key iterator and the validation, are shared by all double arrays.
+type unitAccessor func(i uint64) (unit64, error)
+
+// word is the unit of the 32-bit layout or the 64-bit layout.
+type word interface {
+	~uint32 | ~uint64
+}
+
+// units is the double array of the searches, the units on the heap or the little-endian units of the raw bytes,
+// e.g. the bytes mapped on the memory. The searches are instantiated for each layout,
+// so the units are read without the calls of the function values and the conversions of the units.
+type units[W word] struct {
+	words []W
+	raw   []byte
+}
+
+func (a units[W]) at(i uint64) (W, error) {
+	if a.raw == nil {
+		if i >= uint64(len(a.words)) {
+			return 0, errOutOfBounds
+		}
+		return a.words[i], nil
+	}
+	size := uint64(unsafe.Sizeof(W(0)))
+	if i >= uint64(len(a.raw))/size {
+		return 0, errOutOfBounds
+	}
+	if size == unitSize {
+		return W(binary.LittleEndian.Uint32(a.raw[i*size:])), nil
+	}
+	return W(binary.LittleEndian.Uint64(a.raw[i*size:])), nil
+}
+
+// valueFlag returns the value flag of the layout, the most significant bit.
+func valueFlag[W word]() W {
+	return ^W(0) ^ ^W(0)>>1
+}
+
+// wordLabel returns the label with the value flag, so the units of values never match a label.
+func wordLabel[W word](u W) W {
+	return u & (valueFlag[W]() | 0xFF)
+}
+
+// wordOffset returns the offset of the unit. The extension bit is never set in the 64-bit layout,
+// so the offset of the 32-bit layout is decoded in the same way.
+func wordOffset[W word](u W) uint64 {
+	return uint64(u>>10) << ((u & (1 << 9)) >> 6)
+}
+
+func wordHasLeaf[W word](u W) bool {
+	return (u>>8)&1 == 1
+}
+
+func wordValue[W word](u W) int {
+	return int(uint32(u &^ valueFlag[W]()))
+}
+
+// exactMatchSearch searches the double array by a given keyword and returns the id and it's length if found.
+func exactMatchSearch[W word](a units[W], key string) (id, size int, err error) {
+	nodePos := uint64(0)
+	u, err := a.at(nodePos)
+	if err != nil {
+		return -1, -1, err
+	}
+	for i := 0; i < len(key); i++ {
+		nodePos ^= wordOffset(u) ^ uint64(key[i])
+		u, err = a.at(nodePos)
+		if err != nil {
+			return -1, -1, err
+		}
+		if wordLabel(u) != W(key[i]) {
+			return -1, 0, nil
+		}
+	}
+	if !wordHasLeaf(u) {
+		return -1, 0, nil
+	}
+	u, err = a.at(nodePos ^ wordOffset(u))
+	if err != nil {
+		return -1, -1, err
+	}
+	return wordValue(u), len(key), nil
+}
+
+// commonPrefixSearch finds keywords sharing common prefix in an input and returns the array of pairs (id and it's length) if found.
+func commonPrefixSearch[W word](a units[W], key string, offset int) ([][2]int, error) {
+	var ret [][2]int
+	err := commonPrefixSearchCallback(a, key, offset, func(id, size int) {
+		ret = append(ret, [2]int{id, size})
+	})
+	return ret, err
+}
+
+// commonPrefixSearchCallback finds keywords sharing common prefix in an input and callback with id and it's length.
+func commonPrefixSearchCallback[W word](a units[W], key string, offset int, callback func(id, size int)) error {
+	nodePos := uint64(0)
+	u, err := a.at(nodePos)
+	if err != nil {
+		return err
+	}
+	nodePos ^= wordOffset(u)
+	for i := offset; i < len(key); i++ {
+		k := key[i]
+		nodePos ^= uint64(k)
+		u, err := a.at(nodePos)
+		if err != nil {
+			return err
+		}
+		if wordLabel(u) != W(k) {
+			break
+		}
+		nodePos ^= wordOffset(u)
+		if wordHasLeaf(u) {
+			v, err := a.at(nodePos)
+			if err != nil {
+				return err
+			}
+			callback(wordValue(v), i+1)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// searcher is the double array under the conformance tests.
+type searcher interface {
+	ExactMatchSearch(key string) (id, size int, err error)
+	CommonPrefixSearch(key string, offset int) ([][2]int, error)
+	CommonPrefixSearchCallback(key string, offset int, callback func(id, size int)) error
+	PredictiveSearchCallback(prefix string, callback func(key string, id int)) error
+	Keys(prefix string) *KeyIterator
+}
+
+// searcherFactory builds the double array of the sorted keys and the values,
+// and returns it and the function which releases it.
+type searcherFactory func(t *testing.T, keys []string, values []uint32) (searcher, func())
+
+// searcherFactories are the implementations run by the conformance tests.
+// The implementations mapped on the memory are added on the platforms supporting them.
+var searcherFactories = map[string]searcherFactory{
+	"heap 32-bit": func(t *testing.T, keys []string, values []uint32) (searcher, func()) {
+		a, err := BuildDoubleArray(keys, values, nil)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		return a, func() {}
+	},
+	"heap 64-bit": func(t *testing.T, keys []string, values []uint32) (searcher, func()) {
+		a, err := BuildDoubleArrayUint64(keys, values, nil)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		return a, func() {}
+	},
+}
+
+// conformanceKeys returns the sorted keys and their values, and the queries.
+// The values are chosen so that their low bytes collide with the labels.
+func conformanceKeys() (keys []string, values []uint32, queries []string) {
+	r := rand.New(rand.NewSource(1))
+	// the keys never contain the null character, the queries do.
+	const alphabet = "abc\xff\x00"
+	set := map[string]bool{"電気": true, "電気通信": true, "電気通信大学": true}
+	for len(set) < 500 {
+		b := make([]byte, 1+r.Intn(6))
+		for i := range b {
+			b[i] = alphabet[r.Intn(len(alphabet)-1)]
+		}
+		set[string(b)] = true
+	}
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i := range keys {
+		if i%2 == 0 {
+			values = append(values, uint32(alphabet[r.Intn(len(alphabet))])|uint32(r.Intn(1<<8))<<8)
+			continue
+		}
+		values = append(values, uint32(r.Int31()))
+	}
+	queries = append(queries, "", "\x00", "電", "電気通信大学大学院", "\xff\xff")
+	for _, k := range keys {
+		for i := 1; i <= len(k); i++ {
+			queries = append(queries, k[:i])
+		}
+		for i := 0; i < len(alphabet); i++ {
+			queries = append(queries, k+alphabet[i:i+1])
+		}
+	}
+	return keys, values, queries
+}
+
+func TestSearchConformance(t *testing.T) {
+	keys, values, queries := conformanceKeys()
+	expected := map[string]int{}
+	for i, k := range keys {
+		expected[k] = int(values[i])
+	}
+	for name, factory := range searcherFactories {
+		t.Run(name, func(t *testing.T) {
+			a, release := factory(t, keys, values)
+			defer release()
+			t.Run("exact match search", func(t *testing.T) {
+				for _, q := range queries {
+					id, size, err := a.ExactMatchSearch(q)
+					if err != nil {
+						t.Fatalf("unexpected error, %v", err)
+					}
+					if v, ok := expected[q]; ok && (id != v || size != len(q)) {
+						t.Errorf("expected id=%v, size=%v, got id=%v, size=%v (%q)", v, len(q), id, size, q)
+					} else if !ok && id != -1 {
+						t.Errorf("expected not found, got id=%v, size=%v (%q)", id, size, q)
+					}
+				}
+			})
+			t.Run("common prefix search", func(t *testing.T) {
+				for _, q := range queries {
+					for _, offset := range []int{0, len(q) / 2} {
+						var want [][2]int
+						for i := offset; i < len(q); i++ {
+							if v, ok := expected[q[offset:i+1]]; ok {
+								want = append(want, [2]int{v, i + 1})
+							}
+						}
+						got, err := a.CommonPrefixSearch(q, offset)
+						if err != nil {
+							t.Fatalf("unexpected error, %v", err)
+						}
+						if !reflect.DeepEqual(want, got) {
+							t.Errorf("expected %v, got %v (%q, %v)", want, got, q, offset)
+						}
+						var callback [][2]int
+						if err := a.CommonPrefixSearchCallback(q, offset, func(id, size int) {
+							callback = append(callback, [2]int{id, size})
+						}); err != nil {
+							t.Fatalf("unexpected error, %v", err)
+						}
+						if !reflect.DeepEqual(want, callback) {
+							t.Errorf("callback: expected %v, got %v (%q, %v)", want, callback, q, offset)
+						}
+					}
+				}
+			})
+			t.Run("predictive search", func(t *testing.T) {
+				for _, q := range []string{"", "a", "ab", "c", "\x00", "電気", "d"} {
+					var want, got []string
+					for _, k := range keys {
+						if strings.HasPrefix(k, q) {
+							want = append(want, k)
+						}
+					}
+					if err := a.PredictiveSearchCallback(q, func(key string, id int) {
+						if id != expected[key] {
+							t.Errorf("expected id=%v, got %v (%q)", expected[key], id, key)
+						}
+						got = append(got, key)
+					}); err != nil {
+						t.Fatalf("unexpected error, %v", err)
+					}
+					if !reflect.DeepEqual(want, got) {
+						t.Errorf("expected %v keys, got %v keys (%q)", len(want), len(got), q)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"time"
+)
+
+// BuildStats represents the statistics of the double array built by the builder.
+type BuildStats struct {
+	// NumKeys is the number of the keys.
+	NumKeys int
+	// KeyBytes is the total length of the keys.
+	KeyBytes int64
+	// NumDAWGNodes is the number of the units of the DAWG. It is 0 if the double array is built without the DAWG.
+	NumDAWGNodes int
+	// NumIntersections is the number of the intersection units of the DAWG.
+	NumIntersections int
+	// NumUnits is the number of the units of the double array.
+	NumUnits int
+	// NumFillerUnits is the number of the unused units which are filled by fixing blocks.
+	NumFillerUnits int
+	// Bytes is the size of the serialized double array.
+	Bytes int64
+	// CompressionRatio is the ratio of Bytes to KeyBytes.
+	CompressionRatio float64
+
+	// SortTime is the time to sort the keys.
+	SortTime time.Duration
+	// DAWGTime is the time to build the DAWG. In the parallel build, it is the sum of the time of the partitions.
+	DAWGTime time.Duration
+	// ArrangeTime is the time to arrange the units except for fixing blocks.
+	ArrangeTime time.Duration
+	// FixBlocksTime is the time to fix blocks.
+	FixBlocksTime time.Duration
+}
+
+// Stats returns the statistics of the last build.
+func (b DoubleArrayBuilder) Stats() BuildStats {
+	return b.stats
+}
+
+func (b *DoubleArrayBuilder) finishStats(numKeys int, keyBytes int64) {
+	b.stats.NumKeys = numKeys
+	b.stats.KeyBytes = keyBytes
+	b.stats.NumUnits = b.numUnits()
+	if b.isUint64 {
+		b.stats.Bytes = int64(len(uint64Header) + b.numUnits()*unit64Size)
+	} else {
+		b.stats.Bytes = int64(b.numUnits() * unitSize)
+	}
+	b.stats.CompressionRatio = 0
+	if b.stats.KeyBytes > 0 {
+		b.stats.CompressionRatio = float64(b.stats.Bytes) / float64(b.stats.KeyBytes)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"testing"
+)
+
+func TestDoubleArrayBuilder_Stats(t *testing.T) {
+	keys := []string{
+		"電気",
+		"電気通信",
+		"電気通信大学",
+		"電気通信大学大学院",
+		"電気通信大学大学院大学",
+	}
+	keyBytes := int64(0)
+	for _, k := range keys {
+		keyBytes += int64(len(k))
+	}
+	check := func(t *testing.T, b *DoubleArrayBuilder) BuildStats {
+		t.Helper()
+		stats := b.Stats()
+		if got, expected := stats.NumKeys, len(keys); got != expected {
+			t.Errorf("num keys: expected %v, got %v", expected, got)
+		}
+		if got, expected := stats.KeyBytes, keyBytes; got != expected {
+			t.Errorf("key bytes: expected %v, got %v", expected, got)
+		}
+		if got, expected := stats.NumUnits, b.numUnits(); got != expected {
+			t.Errorf("num units: expected %v, got %v", expected, got)
+		}
+		if got, expected := stats.Bytes, int64(b.numUnits()*unitSize); got != expected {
+			t.Errorf("bytes: expected %v, got %v", expected, got)
+		}
+		if got, expected := stats.CompressionRatio, float64(stats.Bytes)/float64(keyBytes); got != expected {
+			t.Errorf("compression ratio: expected %v, got %v", expected, got)
+		}
+		if stats.NumFillerUnits <= 0 || stats.NumFillerUnits >= stats.NumUnits {
+			t.Errorf("unexpected num filler units, %v", stats.NumFillerUnits)
+		}
+		return stats
+	}
+	t.Run("w/o values", func(t *testing.T) {
+		b := NewDoubleArrayBuilder(nil)
+		if err := b.Build(keys, nil); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		stats := check(t, b)
+		if stats.NumDAWGNodes != 0 || stats.NumIntersections != 0 {
+			t.Errorf("unexpected DAWG stats, %+v", stats)
+		}
+	})
+	t.Run("w/ values", func(t *testing.T) {
+		b := NewDoubleArrayBuilder(nil)
+		if err := b.Build(keys, []uint32{1, 1, 1, 1, 1}); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		stats := check(t, b)
+		if stats.NumDAWGNodes == 0 {
+			t.Errorf("unexpected DAWG stats, %+v", stats)
+		}
+	})
+	t.Run("parallel", func(t *testing.T) {
+		b := NewDoubleArrayBuilder(nil)
+		if err := b.BuildParallel(keys, nil, 2); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		stats := check(t, b)
+		if stats.NumDAWGNodes == 0 {
+			t.Errorf("unexpected DAWG stats, %+v", stats)
+		}
+	})
+	t.Run("64-bit layout", func(t *testing.T) {
+		b := NewDoubleArrayBuilder(nil)
+		b.isUint64 = true
+		if err := b.Build(keys, nil); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if got, expected := b.Stats().Bytes, int64(len(uint64Header)+b.numUnits()*unit64Size); got != expected {
+			t.Errorf("bytes: expected %v, got %v", expected, got)
+		}
+	})
+	t.Run("build error", func(t *testing.T) {
+		b := NewDoubleArrayBuilder(nil)
+		if err := b.Build([]string{"a", "a"}, nil); err == nil {
+			t.Fatalf("expected duplicate key error")
+		}
+		if got := b.Stats(); got != (BuildStats{}) {
+			t.Errorf("expected empty stats, got %+v", got)
+		}
+	})
+}
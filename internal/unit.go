@@ -20,6 +20,15 @@ import (
 
 const maxOffset = 1 << 29
 
+// unit is the unit of the 32-bit layout, which follows the unit of the C++ darts-clone.
+// A unit is a node or a leaf of a node:
+//
+//	node: | 31: 0 | 30-10: offset | 9: extension | 8: has leaf | 7-0: label |
+//	leaf: | 31: 1 | 30-0: value |
+//
+// The children of a node are at offset^label of the child label, which is relative to the index of the node,
+// and the leaf is at offset^0. If the extension bit is set, the offset is shifted left by 8 bits,
+// so offsets up to 1<<29 are available. The root is at index 0 and its label is 0.
 type unit uint32
 
 const unitSize = 4
@@ -53,8 +62,9 @@ func (u *unit) setOffset(offset uint32) error {
 	return nil
 }
 
-func (u unit) label() byte {
-	return byte(uint32(u) & ((1 << 31) | 0xFF))
+// label returns the label with the value flag, so the units of values never match a label.
+func (u unit) label() uint32 {
+	return uint32(u) & ((1 << 31) | 0xFF)
 }
 
 func (u unit) offset() uint32 {
@@ -68,3 +78,12 @@ func (u unit) hasLeaf() bool {
 func (u unit) value() uint32 {
 	return uint32(u) & ((1 << 31) - 1)
 }
+
+// unit64 converts the unit to the unit of the 64-bit layout.
+func (u unit) unit64() unit64 {
+	ret := unit64(uint32(u) & ((1 << 8) | 0xFF))
+	if uint32(u)&(1<<31) != 0 {
+		return unit64(u.value()) | 1<<63
+	}
+	return ret | unit64(u.offset())<<10
+}
@@ -0,0 +1,69 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+)
+
+const maxOffset64 = 1 << 53
+
+// unit64 is the unit of the 64-bit layout.
+// The value flag is the most significant bit and the offset is stored without the extension bit,
+// so offsets up to 1<<53 and 32-bit values are available.
+type unit64 uint64
+
+const unit64Size = 8
+
+func (u *unit64) setHasLeaf(hasLeaf bool) {
+	if hasLeaf {
+		*u = *u | 1<<8
+		return
+	}
+	*u = *u & ^unit64(1<<8)
+}
+
+func (u *unit64) setValue(value uint32) {
+	*u = unit64(uint64(value) | (1 << 63))
+}
+
+func (u *unit64) setLabel(label byte) {
+	*u = *u & ^unit64(0xFF) | unit64(label)
+}
+
+func (u *unit64) setOffset(offset uint64) error {
+	if offset >= maxOffset64 {
+		return fmt.Errorf("failed to modify unit, too large offset")
+	}
+	*u = *u&((1<<63)|(1<<8)|0xFF) | unit64(offset<<10)
+	return nil
+}
+
+// label returns the label with the value flag, so the units of values never match a label.
+func (u unit64) label() uint64 {
+	return uint64(u) & ((1 << 63) | 0xFF)
+}
+
+func (u unit64) offset() uint64 {
+	return uint64(u) >> 10
+}
+
+func (u unit64) hasLeaf() bool {
+	return ((uint64(u) >> 8) & 1) == 1
+}
+
+func (u unit64) value() uint32 {
+	return uint32(uint64(u) & ((1 << 63) - 1))
+}
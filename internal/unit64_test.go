@@ -0,0 +1,92 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"testing"
+)
+
+func TestUnit64_SetOffset_GetOffset(t *testing.T) {
+	t.Run("offset less than 1<<53", func(t *testing.T) {
+		for i := uint64(0); i < 53; i++ {
+			var u unit64
+			for _, x := range []int64{-1, 0, +1} {
+				expected := uint64(int64(uint64(1)<<i) + x)
+				if expected >= maxOffset64 {
+					continue
+				}
+				if err := u.setOffset(expected); err != nil {
+					t.Errorf("unexpected error, %v", err)
+				}
+				if got := u.offset(); got != expected {
+					t.Errorf("expected %v, got %v (%v)", expected, got, i)
+				}
+			}
+		}
+	})
+	t.Run("keep label and leaf flag", func(t *testing.T) {
+		var u unit64
+		u.setLabel('a')
+		u.setHasLeaf(true)
+		if err := u.setOffset(1 << 40); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if got, expected := u.label(), uint64('a'); got != expected {
+			t.Errorf("expected %c, got %c", expected, got)
+		}
+		if !u.hasLeaf() {
+			t.Errorf("expected has leaf")
+		}
+	})
+	t.Run("too large offset", func(t *testing.T) {
+		var u unit64
+		if err := u.setOffset(maxOffset64); err == nil {
+			t.Errorf("expected too large offset error")
+		}
+		if err := u.setOffset(maxOffset64 - 1); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+	})
+}
+
+func TestUnit64_SetLabel(t *testing.T) {
+	var u unit64
+	for _, expected := range []byte{'a', 'b', 'c', '\a', '\n', 0, 0xFF} {
+		u.setLabel(expected)
+		if got := u.label(); got != uint64(expected) {
+			t.Errorf("expected %c, got %c", expected, got)
+		}
+	}
+}
+
+func TestUnit64_SetValue(t *testing.T) {
+	var u unit64
+	for _, expected := range []uint32{0, 1, 2, 3, 1 << 10, 1 << 21, 1<<31 - 1, 1 << 31, 1<<32 - 1} {
+		u.setValue(expected)
+		if got := u.value(); got != expected {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestUnit64_SetHasLeaf(t *testing.T) {
+	var u unit64
+	for _, expected := range []bool{true, false, true, false, false, true} {
+		u.setHasLeaf(expected)
+		if got := u.hasLeaf(); got != expected {
+			t.Errorf("expected %v, got %v", expected, got)
+		}
+	}
+}
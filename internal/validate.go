@@ -0,0 +1,201 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ValidationError is the error of the broken double array, which has the problems of the units.
+type ValidationError struct {
+	// Problems are the problems in the order of the indexes of the units.
+	Problems []UnitProblem
+}
+
+// UnitProblem represents a problem of the unit.
+type UnitProblem struct {
+	Index   uint64
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	p := e.Problems[0]
+	return fmt.Sprintf("broken array, %d problems, unit %d: %s", len(e.Problems), p.Index, p.Message)
+}
+
+// validate walks the double array from the root and returns the ValidationError
+// if a leaf unit has no value flag, an offset escapes the array, a path is cyclic,
+// or a node has no leaf and no children.
+func validate(at unitAccessor, size uint64) error {
+	if size == 0 {
+		return &ValidationError{Problems: []UnitProblem{{Message: "no units"}}}
+	}
+	w := newUnitWalker(at, size)
+	if err := w.traverse(); err != nil {
+		return err
+	}
+	if len(w.anomalies) == 0 {
+		return nil
+	}
+	var ret ValidationError
+	for i, messages := range w.anomalies {
+		for _, m := range messages {
+			ret.Problems = append(ret.Problems, UnitProblem{Index: i, Message: m})
+		}
+	}
+	sort.SliceStable(ret.Problems, func(i, j int) bool {
+		return ret.Problems[i].Index < ret.Problems[j].Index
+	})
+	return &ret
+}
+
+// unit kinds of the walk.
+const (
+	unitUnreachable byte = iota
+	// unitVisiting is the node on the path from the root in the walk, which is reached again by a cycle.
+	unitVisiting
+	unitNode
+	unitLeaf
+)
+
+// unitWalker walks the units reachable from the root and records the anomalies of them.
+// The units can be shared by the multiple parents, which is the intersection of the DAWG.
+type unitWalker struct {
+	at   unitAccessor
+	size uint64
+
+	kinds []byte
+	// parents are the indexes of the parents of the units reached first, which are recorded if not nil.
+	parents   []uint64
+	anomalies map[uint64][]string
+}
+
+func newUnitWalker(at unitAccessor, size uint64) unitWalker {
+	return unitWalker{
+		at:        at,
+		size:      size,
+		kinds:     make([]byte, size),
+		anomalies: map[uint64][]string{},
+	}
+}
+
+func (w *unitWalker) anomaly(i uint64, format string, a ...interface{}) {
+	w.anomalies[i] = append(w.anomalies[i], fmt.Sprintf(format, a...))
+}
+
+// walkFrame is the node on the path from the root.
+type walkFrame struct {
+	pos  uint64
+	base uint64
+	// next is the label of the child to visit next.
+	next        uint64
+	numChildren int
+}
+
+// traverse walks the units reachable from the root in depth first order.
+func (w *unitWalker) traverse() error {
+	if w.size == 0 {
+		return nil
+	}
+	var stack []walkFrame
+	push := func(pos uint64) error {
+		w.kinds[pos] = unitVisiting
+		u, err := w.at(pos)
+		if err != nil {
+			return err
+		}
+		f := walkFrame{pos: pos, base: pos ^ u.offset(), next: 1}
+		if f.base >= w.size {
+			w.anomaly(pos, "offset out of range, base=%d", f.base)
+		}
+		if u.hasLeaf() && f.base >= w.size {
+			w.anomaly(pos, "leaf out of range")
+		} else if u.hasLeaf() {
+			v, err := w.at(f.base)
+			if err != nil {
+				return err
+			}
+			if v.label()&(1<<63) == 0 {
+				w.anomaly(pos, "invalid leaf at %d, no value flag", f.base)
+			} else {
+				if w.kinds[f.base] == unitUnreachable {
+					w.reach(pos, f.base, unitLeaf)
+				}
+				f.numChildren++
+			}
+		}
+		stack = append(stack, f)
+		return nil
+	}
+	if err := push(0); err != nil {
+		return err
+	}
+	for len(stack) > 0 {
+		f := &stack[len(stack)-1]
+		if f.next > 0xFF {
+			// the fillers match the labels under the offset 0, e.g. under the root of the empty TRIE,
+			// so the units of the offset 0 without leaf and children are not dead ends.
+			if f.numChildren == 0 && f.pos != 0 && f.base != f.pos {
+				w.anomaly(f.pos, "dead end, no leaf and no children")
+			}
+			w.kinds[f.pos] = unitNode
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		label := f.next
+		f.next++
+		child := f.base ^ label
+		if child >= w.size {
+			continue
+		}
+		v, err := w.at(child)
+		if err != nil {
+			return err
+		}
+		if v.label() != label {
+			continue
+		}
+		f.numChildren++
+		switch w.kinds[child] {
+		case unitVisiting:
+			w.anomaly(child, "cyclic, reached again from %d", f.pos)
+		case unitUnreachable:
+			w.reach(f.pos, child, unitVisiting)
+			if err := push(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reach records the kind and the parent of the unit reached first.
+func (w *unitWalker) reach(parent, child uint64, kind byte) {
+	w.kinds[child] = kind
+	if w.parents != nil {
+		w.parents[child] = parent
+	}
+}
+
+// Validate walks the double array from the root and returns the ValidationError if the structure is broken.
+func (a DoubleArrayUint32) Validate() error {
+	return validate(a.at64, uint64(len(a.array)))
+}
+
+// Validate walks the double array from the root and returns the ValidationError if the structure is broken.
+func (a DoubleArrayUint64) Validate() error {
+	return validate(a.at, uint64(len(a.array)))
+}
@@ -0,0 +1,141 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	keys := []string{"a", "ab", "abc", "b", "電気"}
+	a32, err := BuildDoubleArray(keys, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	a64, err := BuildDoubleArrayUint64(keys, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	empty, err := BuildDoubleArray(nil, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	for name, a := range map[string]interface{ Validate() error }{"32-bit": a32, "64-bit": a64, "empty": empty} {
+		if err := a.Validate(); err != nil {
+			t.Errorf("%v: unexpected error, %v", name, err)
+		}
+	}
+	if err := (DoubleArrayUint32{}).Validate(); err == nil {
+		t.Errorf("expected error of no units")
+	}
+}
+
+func TestValidate_Conformance(t *testing.T) {
+	keys, values, _ := conformanceKeys()
+	for name, factory := range searcherFactories {
+		t.Run(name, func(t *testing.T) {
+			a, release := factory(t, keys, values)
+			defer release()
+			v, ok := a.(interface{ Validate() error })
+			if !ok {
+				t.Skip("validation is not supported")
+			}
+			if err := v.Validate(); err != nil {
+				t.Errorf("unexpected error, %v", err)
+			}
+		})
+	}
+}
+
+func TestValidate_Broken(t *testing.T) {
+	build := func() DoubleArrayUint32 {
+		a, err := BuildDoubleArray([]string{"a", "ab", "b"}, nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		return *a
+	}
+	// node returns the index of the node of the label under the root.
+	node := func(a DoubleArrayUint32, label byte) int {
+		return int(unit(a.array[0]).offset() ^ uint32(label))
+	}
+	setOffset := func(a DoubleArrayUint32, i int, offset uint32) {
+		u := unit(a.array[i])
+		if err := u.setOffset(offset); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		a.array[i] = uint32(u)
+	}
+	testCases := []struct {
+		name     string
+		modify   func(a DoubleArrayUint32)
+		expected string
+	}{
+		{
+			name: "invalid leaf",
+			modify: func(a DoubleArrayUint32) {
+				i := node(a, 'b')
+				a.array[i^int(unit(a.array[i]).offset())] = 0
+			},
+			expected: "invalid leaf at",
+		},
+		{
+			name: "offset out of range",
+			modify: func(a DoubleArrayUint32) {
+				setOffset(a, node(a, 'b'), uint32(len(a.array))*2)
+			},
+			expected: "offset out of range",
+		},
+		{
+			name: "cyclic",
+			modify: func(a DoubleArrayUint32) {
+				// the child 'a' of the node 'a' is the node itself.
+				setOffset(a, node(a, 'a'), 'a')
+			},
+			expected: "cyclic, reached again",
+		},
+		{
+			name: "dead end",
+			modify: func(a DoubleArrayUint32) {
+				u := unit(a.array[node(a, 'b')])
+				u.setHasLeaf(false)
+				a.array[node(a, 'b')] = uint32(u)
+			},
+			expected: "dead end",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := build()
+			tc.modify(a)
+			err := a.Validate()
+			v, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("expected ValidationError, got %v", err)
+			}
+			var found bool
+			for _, p := range v.Problems {
+				found = found || strings.Contains(p.Message, tc.expected)
+			}
+			if !found {
+				t.Errorf("expected %q, got %+v", tc.expected, v.Problems)
+			}
+			if !strings.HasPrefix(err.Error(), "broken array, ") {
+				t.Errorf("unexpected error message, %v", err)
+			}
+		})
+	}
+}
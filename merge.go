@@ -0,0 +1,119 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"fmt"
+)
+
+// MergeSource represents a TRIE to be merged.
+type MergeSource struct {
+	Trie Trie
+	// Remap maps the id of a key of the TRIE to the id in the merged TRIE.
+	// The id is kept if nil.
+	Remap func(key string, id int) uint32
+}
+
+// ConflictFunc resolves the id of the key found in the multiple sources.
+// The ids are remapped and in the order of the sources.
+type ConflictFunc func(key string, ids []uint32) (uint32, error)
+
+// KeepFirst resolves the conflict by the id of the first source.
+func KeepFirst(key string, ids []uint32) (uint32, error) {
+	return ids[0], nil
+}
+
+// KeepLast resolves the conflict by the id of the last source.
+func KeepLast(key string, ids []uint32) (uint32, error) {
+	return ids[len(ids)-1], nil
+}
+
+// RejectConflict fails the merge if the key is found in the multiple sources with the different ids.
+func RejectConflict(key string, ids []uint32) (uint32, error) {
+	for _, id := range ids[1:] {
+		if id != ids[0] {
+			return 0, fmt.Errorf("conflict, key %v has ids %v", key, ids)
+		}
+	}
+	return ids[0], nil
+}
+
+// Merge builds the TRIE of the keys of the sources.
+// The conflicts of the keys found in the multiple sources are resolved by resolve, KeepFirst if nil.
+// The merged TRIE is written by WriteTo of the builder.
+func Merge(sources []MergeSource, resolve ConflictFunc, progress ProgressFunction) (*Builder, error) {
+	if resolve == nil {
+		resolve = KeepFirst
+	}
+	its := make([]*KeyIterator, len(sources))
+	for i, s := range sources {
+		its[i] = Keys(s.Trie, "")
+		defer its[i].Close()
+	}
+	ok := make([]bool, len(its))
+	for i, it := range its {
+		if ok[i] = it.Next(); !ok[i] {
+			if err := it.Err(); err != nil {
+				return nil, fmt.Errorf("source %d, %v", i, err)
+			}
+		}
+	}
+	var keys []string
+	var values []uint32
+	var ids []uint32
+	for {
+		// the smallest key of the sources.
+		min := -1
+		for i, it := range its {
+			if ok[i] && (min < 0 || it.Key() < its[min].Key()) {
+				min = i
+			}
+		}
+		if min < 0 {
+			break
+		}
+		key := its[min].Key()
+		ids = ids[:0]
+		for i, it := range its {
+			if !ok[i] || it.Key() != key {
+				continue
+			}
+			id := uint32(it.ID())
+			if remap := sources[i].Remap; remap != nil {
+				id = remap(key, it.ID())
+			}
+			ids = append(ids, id)
+			if ok[i] = it.Next(); !ok[i] {
+				if err := it.Err(); err != nil {
+					return nil, fmt.Errorf("source %d, %v", i, err)
+				}
+			}
+		}
+		id := ids[0]
+		if len(ids) > 1 {
+			var err error
+			if id, err = resolve(key, ids); err != nil {
+				return nil, err
+			}
+		}
+		keys = append(keys, key)
+		values = append(values, id)
+	}
+	b := NewBuilder(progress)
+	if err := b.Build(keys, values); err != nil {
+		return nil, fmt.Errorf("build error, %v", err)
+	}
+	return b, nil
+}
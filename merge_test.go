@@ -0,0 +1,101 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	system, err := BuildTRIE([]string{"a", "ab", "b"}, []uint32{1, 2, 3}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	domain, err := BuildTRIE([]string{"ab", "abc", "c"}, []uint32{2, 5, 6}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	testCases := []struct {
+		name     string
+		sources  []MergeSource
+		resolve  ConflictFunc
+		expected map[string]int
+		err      bool
+	}{
+		{
+			name:     "keep first",
+			sources:  []MergeSource{{Trie: system}, {Trie: domain}},
+			expected: map[string]int{"a": 1, "ab": 2, "abc": 5, "b": 3, "c": 6},
+		},
+		{
+			name: "keep last with remap",
+			sources: []MergeSource{
+				{Trie: system},
+				{Trie: domain, Remap: func(key string, id int) uint32 { return uint32(id + 100) }},
+			},
+			resolve:  KeepLast,
+			expected: map[string]int{"a": 1, "ab": 102, "abc": 105, "b": 3, "c": 106},
+		},
+		{
+			name:     "reject the same ids",
+			sources:  []MergeSource{{Trie: system}, {Trie: domain}},
+			resolve:  RejectConflict,
+			expected: map[string]int{"a": 1, "ab": 2, "abc": 5, "b": 3, "c": 6},
+		},
+		{
+			name: "reject the different ids",
+			sources: []MergeSource{
+				{Trie: system},
+				{Trie: domain, Remap: func(key string, id int) uint32 { return uint32(id + 100) }},
+			},
+			resolve: RejectConflict,
+			err:     true,
+		},
+		{
+			name:     "no sources",
+			expected: map[string]int{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := Merge(tc.sources, tc.resolve, nil)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected conflict error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			trie, err := b.DoubleArrayUint32()
+			if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			var n int
+			if err := trie.PredictiveSearchCallback("", func(key string, id int) {
+				if expected, ok := tc.expected[key]; !ok || id != expected {
+					t.Errorf("expected id=%v (%v), got %v (%v)", expected, ok, id, key)
+				}
+				n++
+			}); err != nil {
+				t.Errorf("unexpected error, %v", err)
+			}
+			if n != len(tc.expected) {
+				t.Errorf("expected %v keys, got %v", len(tc.expected), n)
+			}
+		})
+	}
+}
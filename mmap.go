@@ -0,0 +1,96 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"errors"
+
+	"github.com/ikawaha/dartsclone/internal"
+)
+
+// ErrMmapNotSupported is the error returned by OpenMmaped on the platforms without the memory mapping.
+var ErrMmapNotSupported = errors.New("dartsclone: memory mapping is not supported")
+
+// MmapedTrie is the TRIE interface.
+type MmapedTrie interface {
+	Trie
+	// Close deletes mapped memory and closes mapped file.
+	// It waits for the searches in flight, and the searches after Close return ErrClosed.
+	Close() error
+	// Warmup touches all pages of the mapped memory to fault them in.
+	Warmup() error
+}
+
+// MmapError is the error of the system call mapping the file on the memory.
+type MmapError = internal.MmapError
+
+// MmapOption is an option of the memory mapping.
+type MmapOption = internal.MmapOption
+
+// MmapRandom advises that the pages are accessed randomly (MADV_RANDOM), which disables the read-ahead.
+// It is ignored on windows.
+func MmapRandom() MmapOption {
+	return internal.MmapRandom()
+}
+
+// MmapWillNeed advises that the pages will be accessed soon (MADV_WILLNEED), which starts reading them ahead.
+// It is ignored on windows.
+func MmapWillNeed() MmapOption {
+	return internal.MmapWillNeed()
+}
+
+// MmapHugePage advises to back the pages with the transparent huge pages (MADV_HUGEPAGE).
+// It is supported on linux only.
+func MmapHugePage() MmapOption {
+	return internal.MmapHugePage()
+}
+
+// MmapLock locks the pages in the memory (mlock), so they are never paged out.
+// It is not supported on windows.
+func MmapLock() MmapOption {
+	return internal.MmapLock()
+}
+
+// MmapWarmup touches all pages after mapping, in a goroutine if background is true.
+func MmapWarmup(background bool) MmapOption {
+	return internal.MmapWarmup(background)
+}
+
+// OpenAuto opens the named file of the double array.
+// It maps the file on the memory if the platform supports it, otherwise or if the system call mapping the file fails,
+// it falls back to read the file on the heap, where Close and Warmup of the TRIE do nothing.
+// The errors of the options, e.g. MmapLock, are returned without falling back.
+func OpenAuto(name string, opts ...MmapOption) (MmapedTrie, error) {
+	t, err := OpenMmaped(name, opts...)
+	if err == nil {
+		return t, nil
+	}
+	if _, ok := err.(*MmapError); !ok && err != ErrMmapNotSupported {
+		return nil, err
+	}
+	heap, err := Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return heapTrie{Trie: heap}, nil
+}
+
+// heapTrie is the TRIE read on the heap as the MmapedTrie.
+type heapTrie struct {
+	Trie
+}
+
+func (heapTrie) Close() error  { return nil }
+func (heapTrie) Warmup() error { return nil }
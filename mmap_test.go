@@ -0,0 +1,100 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestOpenAuto(t *testing.T) {
+	f, err := ioutil.TempFile("", "trie_open_auto_test")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer os.Remove(f.Name())
+	keys := []string{"hello", "world"}
+	b := NewBuilder(nil)
+	if err := b.Build(keys, nil); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if _, err := b.WriteTo(f); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	f.Close()
+
+	t.Run("open", func(t *testing.T) {
+		trie, err := OpenAuto(f.Name(), MmapRandom())
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		defer trie.Close()
+		for i, key := range keys {
+			if id, size, err := trie.ExactMatchSearch(key); err != nil {
+				t.Errorf("unexpected error, %v", err)
+			} else if id != i || size != len(key) {
+				t.Errorf("expected id=%v, size=%v, got id=%v, size=%v", i, len(key), id, size)
+			}
+		}
+		if err := trie.Warmup(); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+	})
+	t.Run("fall back to the heap", func(t *testing.T) {
+		// an empty file can not be mapped on the memory.
+		empty, err := ioutil.TempFile("", "trie_open_auto_empty_test")
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		defer os.Remove(empty.Name())
+		empty.Close()
+		trie, err := OpenAuto(empty.Name())
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if _, ok := trie.(heapTrie); !ok {
+			t.Errorf("expected the TRIE on the heap, got %T", trie)
+		}
+		if err := trie.Warmup(); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if err := trie.Close(); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+	})
+	t.Run("option error", func(t *testing.T) {
+		opts := []MmapOption{MmapHugePage(), MmapLock()}
+		mmaped, err := OpenMmaped(f.Name(), opts...)
+		if err == ErrMmapNotSupported {
+			t.Skip("memory mapping is not supported")
+		} else if err == nil {
+			mmaped.Close()
+			t.Skip("the options are supported")
+		}
+		if _, ok := err.(*MmapError); ok {
+			t.Fatalf("unexpected error of mapping, %v", err)
+		}
+		if trie, err := OpenAuto(f.Name(), opts...); err == nil {
+			trie.Close()
+			t.Errorf("expected error of the options, got %T", trie)
+		}
+	})
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := OpenAuto(f.Name() + ".missing"); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+}
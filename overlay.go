@@ -0,0 +1,261 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ikawaha/dartsclone/internal"
+)
+
+// OverlayTrie represents the TRIE which layers the additions and the deletions of the keys over a static TRIE.
+// The additions override the keys of the base, and the tombstones hide them.
+// It is safe to search concurrently with the modifications.
+type OverlayTrie struct {
+	base  Trie
+	delta *internal.DynamicDoubleArray
+
+	mu         sync.RWMutex // guards the tombstones and the modifications
+	tombstones map[string]struct{}
+}
+
+// NewOverlayTrie returns the overlay TRIE over the base, which has no additions and deletions.
+func NewOverlayTrie(base Trie) *OverlayTrie {
+	return &OverlayTrie{
+		base:       base,
+		delta:      internal.NewDynamicDoubleArray(),
+		tombstones: map[string]struct{}{},
+	}
+}
+
+// Insert adds the key with the value to the overlay.
+// It returns ErrKeyExists if the key is already in the TRIE, and an error for the zero-length key like the builders.
+func (t *OverlayTrie) Insert(key string, value uint32) error {
+	if len(key) == 0 {
+		return fmt.Errorf("zero-length key")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ok, err := t.has(key); err != nil {
+		return err
+	} else if ok {
+		return ErrKeyExists
+	}
+	return t.delta.Insert(key, value)
+}
+
+// Update sets the value of the key in the overlay.
+// It returns ErrKeyNotFound if the key is not in the TRIE.
+func (t *OverlayTrie) Update(key string, value uint32) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.delta.Update(key, value); err != ErrKeyNotFound {
+		return err
+	}
+	if ok, err := t.inBase(key); err != nil {
+		return err
+	} else if !ok {
+		return ErrKeyNotFound
+	}
+	return t.delta.Insert(key, value)
+}
+
+// Delete removes the key from the overlay, and hides the key of the base by the tombstone.
+// It returns ErrKeyNotFound if the key is not in the TRIE.
+func (t *OverlayTrie) Delete(key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	deleted := t.delta.Delete(key) == nil
+	ok, err := t.inBase(key)
+	if err != nil {
+		return err
+	}
+	if ok {
+		t.tombstones[key] = struct{}{}
+		deleted = true
+	}
+	if !deleted {
+		return ErrKeyNotFound
+	}
+	return nil
+}
+
+// has returns true if the key is in the overlay or in the base.
+func (t *OverlayTrie) has(key string) (bool, error) {
+	if id, _, err := t.delta.ExactMatchSearch(key); err != nil {
+		return false, err
+	} else if id >= 0 {
+		return true, nil
+	}
+	return t.inBase(key)
+}
+
+// inBase returns true if the key is in the base and not deleted.
+func (t *OverlayTrie) inBase(key string) (bool, error) {
+	if _, ok := t.tombstones[key]; ok {
+		return false, nil
+	}
+	id, _, err := t.base.ExactMatchSearch(key)
+	return id >= 0, err
+}
+
+// overridden returns true if the key of the base is deleted or overridden by the overlay.
+func (t *OverlayTrie) overridden(key string) (bool, error) {
+	if _, ok := t.tombstones[key]; ok {
+		return true, nil
+	}
+	id, _, err := t.delta.ExactMatchSearch(key)
+	return id >= 0, err
+}
+
+// ExactMatchSearch searches TRIE by a given keyword and returns the id and it's length if found.
+func (t *OverlayTrie) ExactMatchSearch(key string) (id, size int, err error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if id, size, err := t.delta.ExactMatchSearch(key); err != nil || id >= 0 {
+		return id, size, err
+	}
+	if _, ok := t.tombstones[key]; ok {
+		return -1, 0, nil
+	}
+	return t.base.ExactMatchSearch(key)
+}
+
+// CommonPrefixSearch finds keywords sharing common prefix in an input and returns the array of pairs (id and it's length) if found.
+func (t *OverlayTrie) CommonPrefixSearch(key string, offset int) ([][2]int, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	delta, err := t.delta.CommonPrefixSearch(key, offset)
+	if err != nil {
+		return nil, err
+	}
+	base, err := t.base.CommonPrefixSearch(key, offset)
+	if err != nil {
+		return nil, err
+	}
+	var ret [][2]int
+	for _, v := range base {
+		ok, err := t.overridden(key[offset:v[1]])
+		if err != nil {
+			return nil, err
+		}
+		for len(delta) > 0 && delta[0][1] < v[1] {
+			ret = append(ret, delta[0])
+			delta = delta[1:]
+		}
+		if !ok {
+			ret = append(ret, v)
+		}
+	}
+	return append(ret, delta...), nil
+}
+
+// CommonPrefixSearchCallback finds keywords sharing common prefix in an input and callback with id and it's length.
+// The callback is called after the search releases the lock, so it may search or modify the TRIE.
+func (t *OverlayTrie) CommonPrefixSearchCallback(key string, offset int, callback func(id, size int)) error {
+	ret, err := t.CommonPrefixSearch(key, offset)
+	if err != nil {
+		return err
+	}
+	for _, v := range ret {
+		callback(v[0], v[1])
+	}
+	return nil
+}
+
+// PredictiveSearchCallback finds keywords starting with the prefix and callback with the keyword and it's id in the order of the keywords.
+func (t *OverlayTrie) PredictiveSearchCallback(prefix string, callback func(key string, id int)) error {
+	it := t.Keys(prefix)
+	for it.Next() {
+		callback(it.Key(), it.ID())
+	}
+	return it.Err()
+}
+
+// Keys returns the iterator of the keywords starting with the prefix in the order of the keywords.
+// The keys modified while iterating may be or may not be iterated.
+func (t *OverlayTrie) Keys(prefix string) *KeyIterator {
+	base, delta := Keys(t.base, prefix), t.delta.Keys(prefix)
+	baseOK, deltaOK := base.Next(), delta.Next()
+	ret := internal.NewKeyIteratorFunc(func() (string, int, bool, error) {
+		for baseOK {
+			if deltaOK && delta.Key() <= base.Key() {
+				break
+			}
+			key, id := base.Key(), base.ID()
+			baseOK = base.Next()
+			t.mu.RLock()
+			ok, err := t.overridden(key)
+			t.mu.RUnlock()
+			if err != nil {
+				return "", 0, false, err
+			}
+			if !ok {
+				return key, id, true, nil
+			}
+		}
+		if err := base.Err(); err != nil {
+			return "", 0, false, err
+		}
+		if !deltaOK {
+			return "", 0, false, delta.Err()
+		}
+		key, id := delta.Key(), delta.ID()
+		deltaOK = delta.Next()
+		return key, id, true, nil
+	})
+	internal.SetKeyIteratorRelease(ret, func() {
+		base.Close()
+		delta.Close()
+	})
+	return ret
+}
+
+// Flush builds the TRIE of the keys of the base and the overlay, and writes it to the named file.
+// The file is replaced by renaming, so the base mapped on the memory from the same file is kept intact.
+// The overlay is not changed, open the written file as the base of a new overlay.
+func (t *OverlayTrie) Flush(name string, progress ProgressFunction) error {
+	var keys []string
+	var values []uint32
+	it := t.Keys("")
+	for it.Next() {
+		keys = append(keys, it.Key())
+		values = append(values, uint32(it.ID()))
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	b := NewBuilder(progress)
+	if err := b.Build(keys, values); err != nil {
+		return fmt.Errorf("build error, %v", err)
+	}
+	f, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := b.WriteTo(f); err != nil {
+		f.Close()
+		return fmt.Errorf("write error, %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
@@ -0,0 +1,227 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestOverlayTrie(t *testing.T) {
+	base, err := BuildTRIE([]string{"a", "ab", "abc", "b"}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	trie := NewOverlayTrie(base)
+	if err := trie.Insert("abcd", 10); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if err := trie.Insert("aa", 11); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if err := trie.Insert("ab", 12); err != ErrKeyExists {
+		t.Errorf("expected ErrKeyExists, got %v", err)
+	}
+	// the zero-length key is rejected, so the flush never fails.
+	if err := trie.Insert("", 14); err == nil {
+		t.Errorf("expected zero-length key error")
+	}
+	if err := trie.Update("abc", 13); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if err := trie.Delete("ab"); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if err := trie.Delete("ab"); err != ErrKeyNotFound {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	if err := trie.Update("ab", 1); err != ErrKeyNotFound {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	expected := map[string]int{"a": 0, "aa": 11, "abc": 13, "abcd": 10, "b": 3}
+
+	t.Run("exact match search", func(t *testing.T) {
+		for _, key := range []string{"a", "aa", "ab", "abc", "abcd", "b", "c"} {
+			want, ok := expected[key]
+			if !ok {
+				want = -1
+			}
+			if id, _, err := trie.ExactMatchSearch(key); err != nil || id != want {
+				t.Errorf("expected id=%v, got id=%v, err=%v (%v)", want, id, err, key)
+			}
+		}
+	})
+	t.Run("common prefix search", func(t *testing.T) {
+		ret, err := trie.CommonPrefixSearch("abcde", 0)
+		if err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if want := [][2]int{{0, 1}, {13, 3}, {10, 4}}; !reflect.DeepEqual(want, ret) {
+			t.Errorf("expected %v, got %v", want, ret)
+		}
+	})
+	t.Run("keys", func(t *testing.T) {
+		var keys []string
+		if err := trie.PredictiveSearchCallback("", func(key string, id int) {
+			if id != expected[key] {
+				t.Errorf("expected id=%v, got %v (%v)", expected[key], id, key)
+			}
+			keys = append(keys, key)
+		}); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if want := []string{"a", "aa", "abc", "abcd", "b"}; !reflect.DeepEqual(want, keys) {
+			t.Errorf("expected %v, got %v", want, keys)
+		}
+	})
+	t.Run("flush", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "overlay_flush_test")
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		defer os.RemoveAll(dir)
+		name := filepath.Join(dir, "trie")
+		if err := trie.Flush(name, nil); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		flushed, err := Open(name)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		for key, want := range expected {
+			if id, _, err := flushed.ExactMatchSearch(key); err != nil || id != want {
+				t.Errorf("expected id=%v, got id=%v, err=%v (%v)", want, id, err, key)
+			}
+		}
+		if id, _, _ := flushed.ExactMatchSearch("ab"); id != -1 {
+			t.Errorf("expected the deleted key not found, got %v", id)
+		}
+		if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+			t.Errorf("expected the temporary file removed, got %v files", len(files))
+		}
+	})
+}
+
+func TestOverlayTrie_ModifyInCallback(t *testing.T) {
+	base, err := BuildTRIE([]string{"a", "ab", "abc"}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	trie := NewOverlayTrie(base)
+	var got [][2]int
+	if err := trie.CommonPrefixSearchCallback("abc", 0, func(id, size int) {
+		got = append(got, [2]int{id, size})
+		if err := trie.Delete("abc"); err != nil && err != ErrKeyNotFound {
+			t.Errorf("unexpected error, %v", err)
+		}
+	}); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if expected := [][2]int{{0, 1}, {1, 2}, {2, 3}}; !reflect.DeepEqual(expected, got) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if id, _, err := trie.ExactMatchSearch("abc"); err != nil || id != -1 {
+		t.Errorf("expected the deleted key not found, got id=%v, err=%v", id, err)
+	}
+}
+
+func TestOverlayTrie_Random(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randomKey := func() string {
+		b := make([]byte, 1+r.Intn(4))
+		for i := range b {
+			b[i] = "abc"[r.Intn(3)]
+		}
+		return string(b)
+	}
+	expected := map[string]int{}
+	for len(expected) < 30 {
+		expected[randomKey()] = 0
+	}
+	var keys []string
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		expected[k] = i
+	}
+	base, err := BuildTRIE(keys, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	trie := NewOverlayTrie(base)
+	for i := 0; i < 1000; i++ {
+		key, value := randomKey(), int(r.Int31n(1000))
+		_, ok := expected[key]
+		switch r.Intn(3) {
+		case 0:
+			if err := trie.Insert(key, uint32(value)); ok && err != ErrKeyExists || !ok && err != nil {
+				t.Fatalf("insert %v, unexpected error, %v", key, err)
+			}
+			if !ok {
+				expected[key] = value
+			}
+		case 1:
+			if err := trie.Update(key, uint32(value)); ok && err != nil || !ok && err != ErrKeyNotFound {
+				t.Fatalf("update %v, unexpected error, %v", key, err)
+			}
+			if ok {
+				expected[key] = value
+			}
+		case 2:
+			if err := trie.Delete(key); ok && err != nil || !ok && err != ErrKeyNotFound {
+				t.Fatalf("delete %v, unexpected error, %v", key, err)
+			}
+			delete(expected, key)
+		}
+		query := randomKey() + randomKey()
+		var want [][2]int
+		for j := 1; j <= len(query); j++ {
+			if v, ok := expected[query[:j]]; ok {
+				want = append(want, [2]int{v, j})
+			}
+		}
+		if got, err := trie.CommonPrefixSearch(query, 0); err != nil || !reflect.DeepEqual(want, got) {
+			t.Fatalf("expected %v, got %v, err=%v (%v)", want, got, err, query)
+		}
+	}
+	var want, got []string
+	for k := range expected {
+		if strings.HasPrefix(k, "a") {
+			want = append(want, k)
+		}
+	}
+	sort.Strings(want)
+	it := trie.Keys("a")
+	for it.Next() {
+		if it.ID() != expected[it.Key()] {
+			t.Errorf("expected id=%v, got %v (%v)", expected[it.Key()], it.ID(), it.Key())
+		}
+		got = append(got, it.Key())
+	}
+	if err := it.Err(); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
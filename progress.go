@@ -14,6 +14,10 @@
 
 package dartsclone
 
+import (
+	"github.com/ikawaha/dartsclone/internal"
+)
+
 // ProgressFunction indicates progress bar of building double array.
 type ProgressFunction interface {
 	// SetMaximum sets the maximum of the progress bar.
@@ -21,3 +25,24 @@ type ProgressFunction interface {
 	// Increment with increase the current count on the progress bar.
 	Increment()
 }
+
+// The names of the phases of building double array.
+const (
+	PhaseSort      = internal.PhaseSort
+	PhaseDAWG      = internal.PhaseDAWG
+	PhaseArrange   = internal.PhaseArrange
+	PhaseFixBlocks = internal.PhaseFixBlocks
+	PhaseWrite     = internal.PhaseWrite
+)
+
+// PhaseProgressFunction indicates progress of the named phases of building double array.
+// If the progress function passed to the builder implements this interface,
+// the builder reports the progress of each phase instead of calling SetMaximum and Increment.
+type PhaseProgressFunction interface {
+	// StartPhase starts the named phase with the total count.
+	StartPhase(name string, total int)
+	// IncrementBy increases the current count of the phase by n.
+	IncrementBy(n int)
+	// FinishPhase finishes the named phase.
+	FinishPhase(name string)
+}
@@ -18,7 +18,7 @@ import (
 	progressbar "github.com/schollz/progressbar/v2"
 )
 
-// ProgressBar represents a progress bar that implements ProgressFunction interface.
+// ProgressBar represents a progress bar that implements ProgressFunction and PhaseProgressFunction interfaces.
 type ProgressBar struct {
 	*progressbar.ProgressBar
 }
@@ -39,3 +39,22 @@ func (p *ProgressBar) Increment() {
 		p.Add(1)
 	}
 }
+
+// StartPhase creates a progress bar for the named phase.
+func (p *ProgressBar) StartPhase(name string, total int) {
+	p.ProgressBar = progressbar.NewOptions(total, progressbar.OptionSetDescription(name))
+}
+
+// IncrementBy increases the current count on the progress bar by n.
+func (p *ProgressBar) IncrementBy(n int) {
+	if p.ProgressBar != nil {
+		p.Add(n)
+	}
+}
+
+// FinishPhase fills the progress bar of the phase.
+func (p *ProgressBar) FinishPhase(name string) {
+	if p.ProgressBar != nil {
+		p.Finish()
+	}
+}
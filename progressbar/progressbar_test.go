@@ -41,3 +41,22 @@ func TestProgressBar(t *testing.T) {
 		}
 	})
 }
+
+func TestProgressBar_Phase(t *testing.T) {
+	p := New()
+	t.Run("increments before StartPhase()", func(t *testing.T) {
+		p.IncrementBy(10)
+		p.FinishPhase("none")
+	})
+	t.Run("phases", func(t *testing.T) {
+		for _, name := range []string{"sort", "DAWG", "arrange", "fix blocks", "write"} {
+			p.StartPhase(name, 100)
+			if p.ProgressBar == nil {
+				t.Fatalf("expected progress bar of the phase %v", name)
+			}
+			p.IncrementBy(50)
+			p.IncrementBy(50)
+			p.FinishPhase(name)
+		}
+	})
+}
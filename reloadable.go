@@ -0,0 +1,171 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ikawaha/dartsclone/internal"
+)
+
+// ErrClosed is the error returned by the searches of the closed TRIE.
+var ErrClosed = internal.ErrClosed
+
+// ReloadableTrie is a TRIE which swaps in a newly opened TRIE while searching.
+// The old TRIE is closed after the searches in flight on it finish,
+// so a memory mapped TRIE is never unmapped under a search.
+type ReloadableTrie struct {
+	open func() (Trie, io.Closer, error)
+
+	mu      sync.Mutex // serializes the swaps
+	current atomic.Value
+}
+
+// trieRef counts the references to a TRIE, the searches in flight and the ReloadableTrie itself while it is current.
+type trieRef struct {
+	trie   Trie
+	closer io.Closer
+	refs   int64
+}
+
+func (r *trieRef) release() {
+	if atomic.AddInt64(&r.refs, -1) == 0 && r.closer != nil {
+		r.closer.Close()
+	}
+}
+
+// NewReloadableTrie opens a TRIE by the function and returns the reloadable TRIE of it.
+// The function is called again by Reload. The closer may be nil if the TRIE has nothing to close.
+func NewReloadableTrie(open func() (Trie, io.Closer, error)) (*ReloadableTrie, error) {
+	t, c, err := open()
+	if err != nil {
+		return nil, err
+	}
+	ret := &ReloadableTrie{open: open}
+	ret.current.Store(&trieRef{trie: t, closer: c, refs: 1})
+	return ret, nil
+}
+
+// Reload opens a TRIE by the function given to NewReloadableTrie and swaps it in.
+func (r *ReloadableTrie) Reload() error {
+	t, c, err := r.open()
+	if err != nil {
+		return err
+	}
+	if err := r.Swap(t, c); err != nil {
+		if c != nil {
+			c.Close()
+		}
+		return err
+	}
+	return nil
+}
+
+// Swap swaps in the TRIE. The old TRIE is closed after the searches in flight on it finish.
+func (r *ReloadableTrie) Swap(t Trie, closer io.Closer) error {
+	return r.swap(&trieRef{trie: t, closer: closer, refs: 1})
+}
+
+// Close closes the current TRIE after the searches in flight on it finish.
+// The searches after Close return ErrClosed.
+func (r *ReloadableTrie) Close() error {
+	return r.swap((*trieRef)(nil))
+}
+
+func (r *ReloadableTrie) swap(ref *trieRef) error {
+	r.mu.Lock()
+	old := r.current.Load().(*trieRef)
+	if old == nil {
+		r.mu.Unlock()
+		return ErrClosed
+	}
+	r.current.Store(ref)
+	r.mu.Unlock()
+	old.release()
+	return nil
+}
+
+// acquire returns the current TRIE with a reference, which the caller must release.
+func (r *ReloadableTrie) acquire() (*trieRef, error) {
+	for {
+		ref := r.current.Load().(*trieRef)
+		if ref == nil {
+			return nil, ErrClosed
+		}
+		n := atomic.LoadInt64(&ref.refs)
+		if n == 0 {
+			// swapped out and being closed, loads the new one.
+			continue
+		}
+		if atomic.CompareAndSwapInt64(&ref.refs, n, n+1) {
+			return ref, nil
+		}
+	}
+}
+
+// ExactMatchSearch searches TRIE by a given keyword and returns the id and it's length if found.
+func (r *ReloadableTrie) ExactMatchSearch(key string) (id, size int, err error) {
+	ref, err := r.acquire()
+	if err != nil {
+		return -1, -1, err
+	}
+	defer ref.release()
+	return ref.trie.ExactMatchSearch(key)
+}
+
+// CommonPrefixSearch finds keywords sharing common prefix in an input and returns the array of pairs (id and it's length) if found.
+func (r *ReloadableTrie) CommonPrefixSearch(key string, offset int) ([][2]int, error) {
+	ref, err := r.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer ref.release()
+	return ref.trie.CommonPrefixSearch(key, offset)
+}
+
+// CommonPrefixSearchCallback finds keywords sharing common prefix in an input and callback with id and it's length.
+func (r *ReloadableTrie) CommonPrefixSearchCallback(key string, offset int, callback func(id, size int)) error {
+	ref, err := r.acquire()
+	if err != nil {
+		return err
+	}
+	defer ref.release()
+	return ref.trie.CommonPrefixSearchCallback(key, offset, callback)
+}
+
+// PredictiveSearchCallback finds keywords starting with the prefix and callback with the keyword and it's id in the order of the keywords.
+func (r *ReloadableTrie) PredictiveSearchCallback(prefix string, callback func(key string, id int)) error {
+	ref, err := r.acquire()
+	if err != nil {
+		return err
+	}
+	defer ref.release()
+	return PredictiveSearchCallback(ref.trie, prefix, callback)
+}
+
+// Keys returns the iterator of the keywords starting with the prefix in the order of the keywords.
+// The iterator holds the TRIE until Next returns false or the iterator is closed.
+// The iterator of the closed TRIE returns no keywords and ErrClosed by Err.
+func (r *ReloadableTrie) Keys(prefix string) *KeyIterator {
+	ref, err := r.acquire()
+	if err != nil {
+		return internal.NewErrKeyIterator(err)
+	}
+	it := Keys(ref.trie, prefix)
+	internal.SetKeyIteratorRelease(it, ref.release)
+	return it
+}
@@ -0,0 +1,190 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+type countCloser struct {
+	closed int32
+}
+
+func (c *countCloser) Close() error {
+	atomic.AddInt32(&c.closed, 1)
+	return nil
+}
+
+func (c *countCloser) isClosed() int32 {
+	return atomic.LoadInt32(&c.closed)
+}
+
+// blockingTrie blocks the exact match search until the channel is closed.
+type blockingTrie struct {
+	Trie
+	started chan struct{}
+	block   chan struct{}
+}
+
+func (t blockingTrie) ExactMatchSearch(key string) (id, size int, err error) {
+	close(t.started)
+	<-t.block
+	return t.Trie.ExactMatchSearch(key)
+}
+
+func TestReloadableTrie(t *testing.T) {
+	first, err := BuildTRIE([]string{"hello"}, []uint32{1}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	second, err := BuildTRIE([]string{"hello", "world"}, []uint32{2, 3}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	firstCloser := &countCloser{}
+	r, err := NewReloadableTrie(func() (Trie, io.Closer, error) {
+		return first, firstCloser, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if id, _, err := r.ExactMatchSearch("hello"); err != nil || id != 1 {
+		t.Errorf("expected id=1, got id=%v, err=%v", id, err)
+	}
+
+	it := r.Keys("")
+	secondCloser := &countCloser{}
+	if err := r.Swap(second, secondCloser); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if id, _, err := r.ExactMatchSearch("world"); err != nil || id != 3 {
+		t.Errorf("expected id=3, got id=%v, err=%v", id, err)
+	}
+	if firstCloser.isClosed() != 0 {
+		t.Error("closed while the iterator holds the TRIE")
+	}
+	var keys []string
+	for it.Next() {
+		keys = append(keys, it.Key())
+	}
+	if len(keys) != 1 || keys[0] != "hello" {
+		t.Errorf("expected the keys of the old TRIE, got %v", keys)
+	}
+	if got := firstCloser.isClosed(); got != 1 {
+		t.Errorf("expected closed once, got %v", got)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if got := secondCloser.isClosed(); got != 1 {
+		t.Errorf("expected closed once, got %v", got)
+	}
+	if _, _, err := r.ExactMatchSearch("hello"); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	if it := r.Keys(""); it.Next() || it.Err() != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", it.Err())
+	}
+	if err := r.Close(); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	if err := r.Swap(first, nil); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestReloadableTrie_InFlight(t *testing.T) {
+	trie, err := BuildTRIE([]string{"hello"}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	blocking := blockingTrie{Trie: trie, started: make(chan struct{}), block: make(chan struct{})}
+	closer := &countCloser{}
+	r, err := NewReloadableTrie(func() (Trie, io.Closer, error) {
+		return blocking, closer, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	done := make(chan error)
+	go func() {
+		_, _, err := r.ExactMatchSearch("hello")
+		done <- err
+	}()
+	<-blocking.started
+	if err := r.Swap(trie, nil); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if closer.isClosed() != 0 {
+		t.Error("closed while the search is in flight")
+	}
+	close(blocking.block)
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if got := closer.isClosed(); got != 1 {
+		t.Errorf("expected closed once, got %v", got)
+	}
+}
+
+func TestReloadableTrie_Concurrent(t *testing.T) {
+	trie, err := BuildTRIE([]string{"a", "b", "c"}, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	var mu sync.Mutex
+	var closers []*countCloser
+	r, err := NewReloadableTrie(func() (Trie, io.Closer, error) {
+		c := &countCloser{}
+		mu.Lock()
+		closers = append(closers, c)
+		mu.Unlock()
+		return trie, c, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if id, _, err := r.ExactMatchSearch("b"); err != nil || id != 1 {
+					t.Errorf("expected id=1, got id=%v, err=%v", id, err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		if err := r.Reload(); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+	}
+	wg.Wait()
+	if err := r.Close(); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	for i, c := range closers {
+		if got := c.isClosed(); got != 1 {
+			t.Errorf("expected closed once, got %v (%v)", got, i)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package server serves the searches of a dartsclone TRIE file over HTTP/JSON.
+//
+// The endpoints are:
+//
+//	GET  /exact?key=<key>                    exact match search
+//	GET  /prefix?key=<key>                   common prefix search
+//	GET  /predictive?key=<key>&limit=<n>     predictive search
+//	POST /batch                              searches of {"queries": [{"mode": "exact", "key": "..."}, ...]}
+//	GET  /health                             status of the server
+//
+// Every search responds a result {"mode": "...", "key": "...", "matches": [{"key": "...", "id": 0}, ...]}.
+package server
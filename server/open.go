@@ -0,0 +1,42 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"io"
+
+	"github.com/ikawaha/dartsclone"
+)
+
+// OpenTrie opens the named TRIE file on the heap or maps it on the memory.
+// The closer unmaps the TRIE mapped on the memory, and does nothing for the TRIE on the heap.
+func OpenTrie(name string, mmap bool) (dartsclone.Trie, io.Closer, error) {
+	if mmap {
+		t, err := dartsclone.OpenMmaped(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		return t, t, nil
+	}
+	t, err := dartsclone.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return t, nopCloser{}, nil
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,325 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ikawaha/dartsclone"
+)
+
+// The search modes.
+const (
+	ModeExact      = "exact"
+	ModePrefix     = "prefix"
+	ModePredictive = "predictive"
+)
+
+const (
+	// DefaultPredictiveLimit is the default maximum number of the matches of the predictive search.
+	DefaultPredictiveLimit = 100
+	// maxBatchBytes is the maximum size of the body of the batch request.
+	maxBatchBytes = 10 << 20
+)
+
+// Match represents a key found and it's id.
+type Match struct {
+	Key string `json:"key"`
+	ID  int    `json:"id"`
+}
+
+// Result represents the result of a search.
+type Result struct {
+	Mode    string  `json:"mode"`
+	Key     string  `json:"key"`
+	Matches []Match `json:"matches"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Query represents a search of the batch request.
+type Query struct {
+	Mode  string `json:"mode"`
+	Key   string `json:"key"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+// BatchRequest represents the body of the batch request.
+type BatchRequest struct {
+	Queries []Query `json:"queries"`
+}
+
+// BatchResponse represents the body of the response of the batch request.
+type BatchResponse struct {
+	Results []Result `json:"results"`
+}
+
+// Health represents the status of the server.
+type Health struct {
+	Status   string    `json:"status"`
+	File     string    `json:"file"`
+	Mmap     bool      `json:"mmap"`
+	LoadedAt time.Time `json:"loaded_at"`
+}
+
+// Server serves the searches of a TRIE file.
+// The TRIE is reloaded from the file by Reload, and the searches in flight finish on the old TRIE.
+type Server struct {
+	// ErrorLog logs the errors of the reloads by Watch.
+	// If nil, the standard logger is used.
+	ErrorLog *log.Logger
+
+	name string
+	mmap bool
+	mux  *http.ServeMux
+	trie *dartsclone.ReloadableTrie
+
+	mu       sync.Mutex // guards the status of the file
+	info     os.FileInfo
+	loadedAt time.Time
+	closed   bool
+}
+
+// New opens the named TRIE file and returns the server of it.
+// If mmap is true, the file is mapped on the memory.
+func New(name string, mmap bool) (*Server, error) {
+	s := &Server{
+		name: name,
+		mmap: mmap,
+		mux:  http.NewServeMux(),
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	s.trie, err = dartsclone.NewReloadableTrie(s.open)
+	if err != nil {
+		return nil, err
+	}
+	s.loaded(info)
+	s.mux.HandleFunc("/exact", s.handleSearch(ModeExact))
+	s.mux.HandleFunc("/prefix", s.handleSearch(ModePrefix))
+	s.mux.HandleFunc("/predictive", s.handleSearch(ModePredictive))
+	s.mux.HandleFunc("/batch", s.handleBatch)
+	s.mux.HandleFunc("/health", s.handleHealth)
+	return s, nil
+}
+
+func (s *Server) open() (dartsclone.Trie, io.Closer, error) {
+	t, c, err := OpenTrie(s.name, s.mmap)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open %v, %v", s.name, err)
+	}
+	return t, c, nil
+}
+
+func (s *Server) loaded(info os.FileInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info = info
+	s.loadedAt = time.Now()
+}
+
+// Reload opens the TRIE file again and swaps the TRIE of the server.
+// The old TRIE is closed after the searches in flight finish.
+func (s *Server) Reload() error {
+	info, err := os.Stat(s.name)
+	if err != nil {
+		return err
+	}
+	if err := s.trie.Reload(); err != nil {
+		return err
+	}
+	s.loaded(info)
+	return nil
+}
+
+// Watch polls the TRIE file at the interval and reloads it when the file is modified or replaced, until the context is done.
+func (s *Server) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(s.name)
+		if err != nil {
+			s.logf("watch %v, %v", s.name, err)
+			continue
+		}
+		s.mu.Lock()
+		modified := !s.closed && (!os.SameFile(info, s.info) || !info.ModTime().Equal(s.info.ModTime()) || info.Size() != s.info.Size())
+		s.mu.Unlock()
+		if !modified {
+			continue
+		}
+		if err := s.Reload(); err != nil {
+			s.logf("reload %v, %v", s.name, err)
+		}
+	}
+}
+
+// Close closes the TRIE of the server after the searches in flight finish.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+	return s.trie.Close()
+}
+
+// ServeHTTP serves the searches.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Search searches the TRIE in the mode and returns the result.
+// The limit is the maximum number of the matches of the predictive search, DefaultPredictiveLimit if limit <= 0.
+func (s *Server) Search(mode, key string, limit int) Result {
+	ret := Result{Mode: mode, Key: key, Matches: []Match{}}
+	if err := search(s.trie, &ret, limit); err != nil {
+		ret.Error = err.Error()
+	}
+	return ret
+}
+
+func search(t dartsclone.Trie, ret *Result, limit int) error {
+	switch ret.Mode {
+	case ModeExact:
+		id, _, err := t.ExactMatchSearch(ret.Key)
+		if err != nil {
+			return err
+		}
+		if id >= 0 {
+			ret.Matches = append(ret.Matches, Match{Key: ret.Key, ID: id})
+		}
+	case ModePrefix:
+		if err := t.CommonPrefixSearchCallback(ret.Key, 0, func(id, size int) {
+			ret.Matches = append(ret.Matches, Match{Key: ret.Key[:size], ID: id})
+		}); err != nil {
+			return err
+		}
+	case ModePredictive:
+		if limit <= 0 {
+			limit = DefaultPredictiveLimit
+		}
+		it := dartsclone.Keys(t, ret.Key)
+		defer it.Close()
+		for len(ret.Matches) < limit && it.Next() {
+			ret.Matches = append(ret.Matches, Match{Key: it.Key(), ID: it.ID()})
+		}
+		return it.Err()
+	default:
+		return fmt.Errorf("unknown search mode %q", ret.Mode)
+	}
+	return nil
+}
+
+func (s *Server) handleSearch(mode string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %v not allowed", r.Method))
+			return
+		}
+		q := r.URL.Query()
+		if _, ok := q["key"]; !ok {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("missing key"))
+			return
+		}
+		var limit int
+		if v := q.Get("limit"); v != "" {
+			var err error
+			if limit, err = strconv.Atoi(v); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit, %v", err))
+				return
+			}
+		}
+		ret := s.Search(mode, q.Get("key"), limit)
+		if ret.Error != "" {
+			writeJSON(w, http.StatusInternalServerError, ret)
+			return
+		}
+		writeJSON(w, http.StatusOK, ret)
+	}
+}
+
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %v not allowed", r.Method))
+		return
+	}
+	var req BatchRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBatchBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request, %v", err))
+		return
+	}
+	ret := BatchResponse{Results: make([]Result, 0, len(req.Queries))}
+	for _, q := range req.Queries {
+		ret.Results = append(ret.Results, s.Search(q.Mode, q.Key, q.Limit))
+	}
+	writeJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	ret := Health{
+		Status:   "ok",
+		File:     s.name,
+		Mmap:     s.mmap,
+		LoadedAt: s.loadedAt,
+	}
+	if s.closed {
+		ret.Status = "closed"
+	}
+	s.mu.Unlock()
+	if ret.Status != "ok" {
+		writeJSON(w, http.StatusServiceUnavailable, ret)
+		return
+	}
+	writeJSON(w, http.StatusOK, ret)
+}
+
+func (s *Server) logf(format string, v ...interface{}) {
+	if s.ErrorLog != nil {
+		s.ErrorLog.Printf(format, v...)
+		return
+	}
+	log.Printf(format, v...)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, struct {
+		Error string `json:"error"`
+	}{Error: err.Error()})
+}
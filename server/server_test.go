@@ -0,0 +1,236 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ikawaha/dartsclone"
+)
+
+func writeTrie(t *testing.T, name string, keys []string, values []uint32) {
+	t.Helper()
+	b := dartsclone.NewBuilder(nil)
+	if err := b.Build(keys, values); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := b.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	// replaces the file atomically not to read the file being written.
+	tmp := name + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+}
+
+func newTestServer(t *testing.T) (*Server, string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "server")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	name := filepath.Join(dir, "trie.dic")
+	writeTrie(t, name, []string{"電気", "電気通信", "電気通信大学", "hello"}, []uint32{1, 2, 3, 4})
+	s, err := New(name, false)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("unexpected error, %v", err)
+	}
+	return s, name, func() {
+		s.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestServer_Search(t *testing.T) {
+	s, _, cleanup := newTestServer(t)
+	defer cleanup()
+	testCases := []struct {
+		path     string
+		code     int
+		expected Result
+	}{
+		{
+			path:     "/exact?key=" + "電気通信",
+			code:     http.StatusOK,
+			expected: Result{Mode: ModeExact, Key: "電気通信", Matches: []Match{{Key: "電気通信", ID: 2}}},
+		},
+		{
+			path:     "/exact?key=" + "電",
+			code:     http.StatusOK,
+			expected: Result{Mode: ModeExact, Key: "電", Matches: []Match{}},
+		},
+		{
+			path: "/prefix?key=" + "電気通信大学院",
+			code: http.StatusOK,
+			expected: Result{Mode: ModePrefix, Key: "電気通信大学院", Matches: []Match{
+				{Key: "電気", ID: 1}, {Key: "電気通信", ID: 2}, {Key: "電気通信大学", ID: 3},
+			}},
+		},
+		{
+			path: "/predictive?key=" + "電気通",
+			code: http.StatusOK,
+			expected: Result{Mode: ModePredictive, Key: "電気通", Matches: []Match{
+				{Key: "電気通信", ID: 2}, {Key: "電気通信大学", ID: 3},
+			}},
+		},
+		{
+			path: "/predictive?limit=1&key=" + "電気",
+			code: http.StatusOK,
+			expected: Result{Mode: ModePredictive, Key: "電気", Matches: []Match{
+				{Key: "電気", ID: 1},
+			}},
+		},
+	}
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != tc.code {
+			t.Errorf("expected status %v, got %v, %v (%v)", tc.code, w.Code, w.Body.String(), tc.path)
+			continue
+		}
+		var got Result
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("expected %+v, got %+v (%v)", tc.expected, got, tc.path)
+		}
+	}
+
+	t.Run("bad requests", func(t *testing.T) {
+		for _, tc := range []struct {
+			method string
+			path   string
+			code   int
+		}{
+			{method: http.MethodGet, path: "/exact", code: http.StatusBadRequest},
+			{method: http.MethodGet, path: "/predictive?key=a&limit=x", code: http.StatusBadRequest},
+			{method: http.MethodPost, path: "/exact?key=a", code: http.StatusMethodNotAllowed},
+			{method: http.MethodGet, path: "/batch", code: http.StatusMethodNotAllowed},
+		} {
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+			if w.Code != tc.code {
+				t.Errorf("expected status %v, got %v (%v %v)", tc.code, w.Code, tc.method, tc.path)
+			}
+		}
+	})
+}
+
+func TestServer_Batch(t *testing.T) {
+	s, _, cleanup := newTestServer(t)
+	defer cleanup()
+	body := `{"queries": [{"mode": "exact", "key": "hello"}, {"mode": "prefix", "key": "電気通信"}, {"mode": "unknown", "key": "a"}]}`
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %v, got %v, %v", http.StatusOK, w.Code, w.Body.String())
+	}
+	var got BatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if len(got.Results) != 3 {
+		t.Fatalf("expected 3 results, got %+v", got)
+	}
+	if expected := []Match{{Key: "hello", ID: 4}}; !reflect.DeepEqual(got.Results[0].Matches, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got.Results[0].Matches)
+	}
+	if got, expected := len(got.Results[1].Matches), 2; got != expected {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	if got.Results[2].Error == "" {
+		t.Error("expected unknown search mode error")
+	}
+
+	t.Run("invalid body", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader("{")))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected status %v, got %v", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestServer_Health(t *testing.T) {
+	s, name, cleanup := newTestServer(t)
+	defer cleanup()
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var got Health
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if w.Code != http.StatusOK || got.Status != "ok" || got.File != name {
+		t.Errorf("unexpected health, %v, %+v", w.Code, got)
+	}
+	s.Close()
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %v, got %v", http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+func TestServer_Reload(t *testing.T) {
+	s, name, cleanup := newTestServer(t)
+	defer cleanup()
+	writeTrie(t, name, []string{"world"}, []uint32{5})
+	if err := s.Reload(); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if ret := s.Search(ModeExact, "world", 0); len(ret.Matches) != 1 || ret.Matches[0].ID != 5 {
+		t.Errorf("unexpected result, %+v", ret)
+	}
+
+	t.Run("watch", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			s.Watch(ctx, 10*time.Millisecond)
+			close(done)
+		}()
+		defer func() {
+			cancel()
+			<-done
+		}()
+		writeTrie(t, name, []string{"hello", "world"}, []uint32{6, 7})
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if ret := s.Search(ModeExact, "hello", 0); len(ret.Matches) == 1 && ret.Matches[0].ID == 6 {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Error("expected reload by watch")
+	})
+}
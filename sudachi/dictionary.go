@@ -0,0 +1,131 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sudachi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ikawaha/dartsclone"
+)
+
+// WordIDTable is the table of the word IDs which the values of the TRIE point into.
+type WordIDTable []byte
+
+// Get returns the word IDs at the index, the value of the TRIE.
+// The IDs are stored after their number in a byte.
+func (t WordIDTable) Get(index int) ([]uint32, error) {
+	if index < 0 || index >= len(t) {
+		return nil, fmt.Errorf("index out of bounds")
+	}
+	n := int(t[index])
+	index++
+	if index+n*4 > len(t) {
+		return nil, fmt.Errorf("broken word ID table at %d", index-1)
+	}
+	ret := make([]uint32, n)
+	for i := range ret {
+		ret[i] = binary.LittleEndian.Uint32(t[index+i*4:])
+	}
+	return ret, nil
+}
+
+// Dictionary represents the TRIE and the word ID table of the dictionary.
+type Dictionary struct {
+	Layout
+	trie    dartsclone.Trie
+	wordIDs WordIDTable
+	closer  io.Closer
+}
+
+// Open opens the named file of the dictionary and reads the TRIE on the heap.
+func Open(name string) (*Dictionary, error) {
+	l, table, err := open(name)
+	if err != nil {
+		return nil, err
+	}
+	t, err := dartsclone.OpenSection(name, l.TrieOffset, l.TrieSize)
+	if err != nil {
+		return nil, fmt.Errorf("open TRIE, %v", err)
+	}
+	return &Dictionary{Layout: *l, trie: t, wordIDs: table}, nil
+}
+
+// OpenMmaped opens the named file of the dictionary and maps the TRIE on the memory.
+// The word ID table is read on the heap.
+func OpenMmaped(name string, opts ...dartsclone.MmapOption) (*Dictionary, error) {
+	l, table, err := open(name)
+	if err != nil {
+		return nil, err
+	}
+	t, err := dartsclone.OpenMmapedSection(name, l.TrieOffset, l.TrieSize, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("open TRIE, %v", err)
+	}
+	return &Dictionary{Layout: *l, trie: t, wordIDs: table, closer: t}, nil
+}
+
+func open(name string) (*Layout, WordIDTable, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	l, err := ReadLayout(f, info.Size())
+	if err != nil {
+		return nil, nil, err
+	}
+	table := make(WordIDTable, l.WordIDTableSize)
+	if _, err := f.ReadAt(table, l.WordIDTableOffset); err != nil {
+		return nil, nil, fmt.Errorf("read word ID table, %v", err)
+	}
+	return l, table, nil
+}
+
+// Trie returns the TRIE of the dictionary, the values are the indexes of the word ID table.
+func (d *Dictionary) Trie() dartsclone.Trie {
+	return d.trie
+}
+
+// WordIDTable returns the word ID table of the dictionary.
+func (d *Dictionary) WordIDTable() WordIDTable {
+	return d.wordIDs
+}
+
+// Lookup searches the TRIE by the key and returns the word IDs of it, or nil if not found.
+func (d *Dictionary) Lookup(key string) ([]uint32, error) {
+	id, _, err := d.trie.ExactMatchSearch(key)
+	if err != nil {
+		return nil, err
+	}
+	if id < 0 {
+		return nil, nil
+	}
+	return d.wordIDs.Get(id)
+}
+
+// Close unmaps the TRIE if it is mapped on the memory.
+func (d *Dictionary) Close() error {
+	if d.closer == nil {
+		return nil
+	}
+	return d.closer.Close()
+}
@@ -0,0 +1,215 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sudachi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/ikawaha/dartsclone"
+)
+
+var testEntries = []struct {
+	key string
+	ids []uint32
+}{
+	{key: "東京", ids: []uint32{0}},
+	{key: "東京都", ids: []uint32{1, 2}},
+	{key: "都", ids: []uint32{3}},
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	u := utf16.Encode([]rune(s))
+	if len(u) >= 0x80 {
+		buf.WriteByte(byte(len(u)>>8 | 0x80))
+	}
+	buf.WriteByte(byte(len(u)))
+	binary.Write(buf, binary.LittleEndian, u)
+}
+
+// writeDictionary writes the dictionary of the test entries, its word information is omitted.
+func writeDictionary(t *testing.T, version uint64, grammar bool) string {
+	t.Helper()
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, version)
+	binary.Write(&buf, binary.LittleEndian, int64(1546300800))
+	var desc [descriptionSize]byte
+	copy(desc[:], "test dictionary")
+	buf.Write(desc[:])
+	if grammar {
+		pos := []string{"名詞", "固有名詞", "地名", "一般", "*", strings.Repeat("長", 200)}
+		binary.Write(&buf, binary.LittleEndian, int16(1))
+		for _, s := range pos {
+			writeString(&buf, s)
+		}
+		binary.Write(&buf, binary.LittleEndian, []int16{2, 3, 0, 1, 2, 3, 4, 5})
+	}
+
+	var table bytes.Buffer
+	var keys []string
+	var values []uint32
+	for _, e := range testEntries {
+		keys = append(keys, e.key)
+		values = append(values, uint32(table.Len()))
+		table.WriteByte(byte(len(e.ids)))
+		binary.Write(&table, binary.LittleEndian, e.ids)
+	}
+	b := dartsclone.NewBuilder(nil)
+	if err := b.Build(keys, values); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	var trie bytes.Buffer
+	if _, err := b.WriteDartsCloneTo(&trie); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	binary.Write(&buf, binary.LittleEndian, int32(trie.Len()/4))
+	buf.Write(trie.Bytes())
+	binary.Write(&buf, binary.LittleEndian, int32(table.Len()))
+	buf.Write(table.Bytes())
+	buf.WriteString("word parameters and word information")
+
+	f, err := ioutil.TempFile("", "sudachi_dictionary_test")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	return f.Name()
+}
+
+func TestOpen(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		version uint64
+		grammar bool
+		system  bool
+	}{
+		{name: "system v1", version: SystemDictVersion1, grammar: true, system: true},
+		{name: "system v2", version: SystemDictVersion2, grammar: true, system: true},
+		{name: "user v1", version: UserDictVersion1},
+		{name: "user v2", version: UserDictVersion2, grammar: true},
+		{name: "user v3", version: UserDictVersion3, grammar: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			name := writeDictionary(t, tt.version, tt.grammar)
+			defer os.Remove(name)
+			for mode, open := range map[string]func(string) (*Dictionary, error){
+				"heap": Open,
+				"mmap": func(name string) (*Dictionary, error) { return OpenMmaped(name) },
+			} {
+				d, err := open(name)
+				if err == dartsclone.ErrMmapNotSupported {
+					continue
+				}
+				if err != nil {
+					t.Fatalf("%s: unexpected error, %v", mode, err)
+				}
+				if d.Header.Version != tt.version || d.Header.IsSystem() != tt.system || d.Header.IsUser() == tt.system {
+					t.Errorf("%s: unexpected header, %+v", mode, d.Header)
+				}
+				if expected := "test dictionary"; d.Header.Description != expected {
+					t.Errorf("%s: expected %q, got %q", mode, expected, d.Header.Description)
+				}
+				if expected := int64(1546300800); d.Header.CreateTime.Unix() != expected {
+					t.Errorf("%s: expected %v, got %v", mode, expected, d.Header.CreateTime.Unix())
+				}
+				for _, e := range testEntries {
+					ids, err := d.Lookup(e.key)
+					if err != nil {
+						t.Errorf("%s: unexpected error, %v", mode, err)
+					} else if !reflect.DeepEqual(ids, e.ids) {
+						t.Errorf("%s: key %q, expected %v, got %v", mode, e.key, e.ids, ids)
+					}
+				}
+				if ids, err := d.Lookup("京"); err != nil || ids != nil {
+					t.Errorf("%s: expected not found, got %v, %v", mode, ids, err)
+				}
+				var got [][]uint32
+				err = d.Trie().CommonPrefixSearchCallback("東京都庁", 0, func(id, size int) {
+					ids, err := d.WordIDTable().Get(id)
+					if err != nil {
+						t.Errorf("%s: unexpected error, %v", mode, err)
+					}
+					got = append(got, ids)
+				})
+				if err != nil {
+					t.Errorf("%s: unexpected error, %v", mode, err)
+				}
+				if expected := [][]uint32{{0}, {1, 2}}; !reflect.DeepEqual(got, expected) {
+					t.Errorf("%s: expected %v, got %v", mode, expected, got)
+				}
+				if err := d.Close(); err != nil {
+					t.Errorf("%s: unexpected error, %v", mode, err)
+				}
+			}
+		})
+	}
+}
+
+func TestOpen_Error(t *testing.T) {
+	t.Run("unknown version", func(t *testing.T) {
+		name := writeDictionary(t, 0x0123456789abcdef, true)
+		defer os.Remove(name)
+		if _, err := Open(name); err == nil || !strings.Contains(err.Error(), "unknown dictionary version") {
+			t.Errorf("expected error of the unknown version, got %v", err)
+		}
+	})
+	t.Run("truncated", func(t *testing.T) {
+		name := writeDictionary(t, SystemDictVersion1, true)
+		defer os.Remove(name)
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		l, err := ReadLayout(bytes.NewReader(b), int64(len(b)))
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		for _, size := range []int64{headerSize - 1, l.TrieOffset + l.TrieSize/2, l.WordIDTableOffset + 1} {
+			if _, err := ReadLayout(bytes.NewReader(b[:size]), size); err == nil {
+				t.Errorf("size %d: expected error", size)
+			}
+		}
+	})
+	t.Run("user v1 with grammar", func(t *testing.T) {
+		name := writeDictionary(t, UserDictVersion1, true)
+		defer os.Remove(name)
+		if _, err := Open(name); err == nil {
+			t.Errorf("expected error")
+		}
+	})
+}
+
+func TestWordIDTable_Get(t *testing.T) {
+	table := WordIDTable{2, 1, 0, 0, 0, 0, 1, 0, 0, 1, 7, 0, 0}
+	if got, err := table.Get(0); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	} else if expected := []uint32{1, 256}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	for _, index := range []int{-1, 9, len(table)} {
+		if _, err := table.Get(index); err == nil {
+			t.Errorf("index %d: expected error", index)
+		}
+	}
+}
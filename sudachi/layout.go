@@ -0,0 +1,218 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sudachi reads the TRIE of the binary dictionary of Sudachi, https://github.com/WorksApplications/Sudachi.
+// The dictionary consists of the header, the grammar and the lexicon, and the lexicon starts with the TRIE
+// of the darts-clone and the word ID table which the values of the TRIE point into.
+package sudachi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// The versions of the dictionary, the first 8 bytes of the header.
+const (
+	SystemDictVersion1 uint64 = 0x7366d3f18bd111e7
+	SystemDictVersion2 uint64 = 0xce9f011a92394434
+	UserDictVersion1   uint64 = 0xa50f31188bd211e7
+	UserDictVersion2   uint64 = 0x9fdeb5a90168d868
+	UserDictVersion3   uint64 = 0xca9811756ff64fb0
+)
+
+const (
+	headerSize      = 272
+	descriptionSize = 256
+	// numPOSFields is the number of the strings of a part of speech.
+	numPOSFields = 6
+)
+
+// Header represents the header of the dictionary.
+type Header struct {
+	Version     uint64
+	CreateTime  time.Time
+	Description string
+}
+
+// IsSystem returns true if the dictionary is a system dictionary.
+func (h Header) IsSystem() bool {
+	return h.Version == SystemDictVersion1 || h.Version == SystemDictVersion2
+}
+
+// IsUser returns true if the dictionary is a user dictionary.
+func (h Header) IsUser() bool {
+	return h.Version == UserDictVersion1 || h.Version == UserDictVersion2 || h.Version == UserDictVersion3
+}
+
+// hasGrammar returns true if the grammar follows the header, the user dictionaries of the version 1 have no grammar.
+func (h Header) hasGrammar() bool {
+	return h.IsSystem() || h.Version == UserDictVersion2 || h.Version == UserDictVersion3
+}
+
+// Layout represents the sections of the dictionary in bytes.
+type Layout struct {
+	Header Header
+	// TrieOffset and TrieSize are the section of the units of the TRIE.
+	TrieOffset, TrieSize int64
+	// WordIDTableOffset and WordIDTableSize are the section of the word ID table.
+	WordIDTableOffset, WordIDTableSize int64
+}
+
+// ReadLayout reads the header and locates the sections of the dictionary of the size.
+func ReadLayout(r io.ReaderAt, size int64) (*Layout, error) {
+	var buf [headerSize]byte
+	if _, err := r.ReadAt(buf[:], 0); err != nil {
+		return nil, fmt.Errorf("read header, %v", err)
+	}
+	h := Header{
+		Version:    binary.LittleEndian.Uint64(buf[0:8]),
+		CreateTime: time.Unix(int64(binary.LittleEndian.Uint64(buf[8:16])), 0),
+	}
+	desc := buf[16 : 16+descriptionSize]
+	if i := bytes.IndexByte(desc, 0); i >= 0 {
+		desc = desc[:i]
+	}
+	h.Description = string(desc)
+	if !h.IsSystem() && !h.IsUser() {
+		return nil, fmt.Errorf("unknown dictionary version, 0x%016x", h.Version)
+	}
+
+	p := &reader{r: r, offset: headerSize, size: size}
+	if h.hasGrammar() {
+		if err := p.skipGrammar(); err != nil {
+			return nil, fmt.Errorf("read grammar, %v", err)
+		}
+	}
+	trieSize, err := p.int32()
+	if err != nil {
+		return nil, fmt.Errorf("read lexicon, %v", err)
+	}
+	ret := Layout{
+		Header:     h,
+		TrieOffset: p.offset,
+		TrieSize:   int64(trieSize) * 4,
+	}
+	if err := p.skip(ret.TrieSize); err != nil {
+		return nil, fmt.Errorf("read TRIE, %v", err)
+	}
+	tableSize, err := p.int32()
+	if err != nil {
+		return nil, fmt.Errorf("read word ID table, %v", err)
+	}
+	ret.WordIDTableOffset = p.offset
+	ret.WordIDTableSize = int64(tableSize)
+	if err := p.skip(ret.WordIDTableSize); err != nil {
+		return nil, fmt.Errorf("read word ID table, %v", err)
+	}
+	return &ret, nil
+}
+
+// reader reads the little-endian numbers of the dictionary sequentially.
+type reader struct {
+	r      io.ReaderAt
+	offset int64
+	size   int64
+}
+
+func (p *reader) read(n int64) ([]byte, error) {
+	if err := p.check(n); err != nil {
+		return nil, err
+	}
+	b := make([]byte, n)
+	if _, err := p.r.ReadAt(b, p.offset); err != nil {
+		return nil, err
+	}
+	p.offset += n
+	return b, nil
+}
+
+func (p *reader) check(n int64) error {
+	if n < 0 || p.offset+n > p.size {
+		return fmt.Errorf("out of the file, offset=%v, size=%v, file size=%v", p.offset, n, p.size)
+	}
+	return nil
+}
+
+func (p *reader) skip(n int64) error {
+	if err := p.check(n); err != nil {
+		return err
+	}
+	p.offset += n
+	return nil
+}
+
+func (p *reader) int16() (int16, error) {
+	b, err := p.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return int16(binary.LittleEndian.Uint16(b)), nil
+}
+
+func (p *reader) int32() (int32, error) {
+	b, err := p.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(b)), nil
+}
+
+// skipString skips the string of the UTF-16 characters after its length,
+// the length of 128 or longer is 2 bytes with the most significant bit set.
+func (p *reader) skipString() error {
+	b, err := p.read(1)
+	if err != nil {
+		return err
+	}
+	n := int64(b[0])
+	if n&0x80 != 0 {
+		low, err := p.read(1)
+		if err != nil {
+			return err
+		}
+		n = (n&0x7F)<<8 | int64(low[0])
+	}
+	return p.skip(n * 2)
+}
+
+// skipGrammar skips the parts of speech and the connection matrix.
+func (p *reader) skipGrammar() error {
+	numPOS, err := p.int16()
+	if err != nil {
+		return err
+	}
+	if numPOS < 0 {
+		return fmt.Errorf("invalid number of the parts of speech, %v", numPOS)
+	}
+	for i := 0; i < int(numPOS)*numPOSFields; i++ {
+		if err := p.skipString(); err != nil {
+			return err
+		}
+	}
+	left, err := p.int16()
+	if err != nil {
+		return err
+	}
+	right, err := p.int16()
+	if err != nil {
+		return err
+	}
+	if left < 0 || right < 0 {
+		return fmt.Errorf("invalid size of the connection matrix, %vx%v", left, right)
+	}
+	return p.skip(int64(left) * int64(right) * 2)
+}
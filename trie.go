@@ -15,6 +15,8 @@
 package dartsclone
 
 import (
+	"fmt"
+
 	"github.com/ikawaha/dartsclone/internal"
 )
 
@@ -28,7 +30,119 @@ type Trie interface {
 	CommonPrefixSearchCallback(key string, offset int, callback func(id, size int)) error
 }
 
+// KeyIterator iterates the keywords of the TRIE and their ids.
+type KeyIterator = internal.KeyIterator
+
+// keyLister is the TRIE which lists the keywords in the order of the keywords.
+type keyLister interface {
+	PredictiveSearchCallback(prefix string, callback func(key string, id int)) error
+	Keys(prefix string) *KeyIterator
+}
+
+// PredictiveSearchCallback finds keywords of the TRIE starting with the prefix and callback with the keyword and it's id in the order of the keywords.
+// The TRIE lists the keywords by the methods PredictiveSearchCallback and Keys, which all TRIEs of this package have.
+func PredictiveSearchCallback(t Trie, prefix string, callback func(key string, id int)) error {
+	if h, ok := t.(heapTrie); ok {
+		t = h.Trie
+	}
+	l, ok := t.(keyLister)
+	if !ok {
+		return fmt.Errorf("predictive search is not supported by %T", t)
+	}
+	return l.PredictiveSearchCallback(prefix, callback)
+}
+
+// Keys returns the iterator of the keywords of the TRIE starting with the prefix in the order of the keywords.
+// The iterator of the TRIE which does not list the keywords returns no keywords and the error by Err.
+func Keys(t Trie, prefix string) *KeyIterator {
+	if h, ok := t.(heapTrie); ok {
+		t = h.Trie
+	}
+	l, ok := t.(keyLister)
+	if !ok {
+		return internal.NewErrKeyIterator(fmt.Errorf("predictive search is not supported by %T", t))
+	}
+	return l.Keys(prefix)
+}
+
+// Layout represents the layout of the units in the file of the double array.
+type Layout = internal.Layout
+
+// FileLayout returns the layout of the named file of the double array, 32-bit or 64-bit.
+func FileLayout(name string) (Layout, error) {
+	return internal.FileLayout(name)
+}
+
+// OpenOption is an option of Open.
+type OpenOption func(*openOptions)
+
+type openOptions struct {
+	validate bool
+}
+
+// OpenValidate validates the structure of the double array after reading it, see Validate.
+func OpenValidate() OpenOption {
+	return func(o *openOptions) {
+		o.validate = true
+	}
+}
+
 // Open opens the named file of the double array.
-func Open(name string) (Trie, error) {
+// The unit layout, 32-bit or 64-bit, is detected from the file.
+func Open(name string, opts ...OpenOption) (Trie, error) {
+	var o openOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	t, err := open(name)
+	if err != nil {
+		return nil, err
+	}
+	if o.validate {
+		if err := Validate(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// OpenSection opens the named file and reads the double array in the section of it.
+// The unit layout, 32-bit or 64-bit, is detected from the section.
+func OpenSection(name string, offset, size int64, opts ...OpenOption) (Trie, error) {
+	var o openOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	t, err := openSection(name, offset, size)
+	if err != nil {
+		return nil, err
+	}
+	if o.validate {
+		if err := Validate(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func openSection(name string, offset, size int64) (Trie, error) {
+	ok, err := internal.IsUint64FileSection(name, offset, size)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return internal.OpenSectionUint64(name, offset, size)
+	}
+	return internal.OpenSection(name, offset, size)
+}
+
+func open(name string) (Trie, error) {
+	ok, err := internal.IsUint64File(name)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return internal.OpenUint64(name)
+	}
 	return internal.Open(name)
 }
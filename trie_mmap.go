@@ -12,7 +12,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// +build mmap
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris windows
 
 package dartsclone
 
@@ -20,14 +20,29 @@ import (
 	"github.com/ikawaha/dartsclone/internal"
 )
 
-// MmapedTrie is the TRIE interface.
-type MmapedTrie interface {
-	Trie
-	// Close deletes mapped memory and closes mapped file.
-	Close() error
+// OpenMmaped opens the named file of the double array and maps it on the memory.
+// The unit layout, 32-bit or 64-bit, is detected from the file.
+// The options advise the access pattern of the mapped memory, lock it or warm it up.
+func OpenMmaped(name string, opts ...MmapOption) (MmapedTrie, error) {
+	ok, err := internal.IsUint64File(name)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return internal.OpenMmapedUint64(name, opts...)
+	}
+	return internal.OpenMmaped(name, opts...)
 }
 
-// OpenMmaped opens the named file of the double array and maps it on the memory.
-func OpenMmaped(name string) (MmapedTrie, error) {
-	return internal.OpenMmaped(name)
+// OpenMmapedSection opens the named file and maps the double array in the section of it on the memory,
+// e.g. the TRIE embedded in a larger file. The offset need not be a multiple of the page size.
+func OpenMmapedSection(name string, offset, size int64, opts ...MmapOption) (MmapedTrie, error) {
+	ok, err := internal.IsUint64FileSection(name, offset, size)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return internal.OpenMmapedSectionUint64(name, offset, size, opts...)
+	}
+	return internal.OpenMmapedSection(name, offset, size, opts...)
 }
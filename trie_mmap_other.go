@@ -0,0 +1,29 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris,!windows
+
+package dartsclone
+
+// OpenMmaped opens the named file of the double array and maps it on the memory.
+// The memory mapping is not supported on this platform, so it always returns ErrMmapNotSupported.
+func OpenMmaped(name string, opts ...MmapOption) (MmapedTrie, error) {
+	return nil, ErrMmapNotSupported
+}
+
+// OpenMmapedSection opens the named file and maps the double array in the section of it on the memory.
+// The memory mapping is not supported on this platform, so it always returns ErrMmapNotSupported.
+func OpenMmapedSection(name string, offset, size int64, opts ...MmapOption) (MmapedTrie, error) {
+	return nil, ErrMmapNotSupported
+}
@@ -12,12 +12,14 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// +build mmap
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris windows
 
 package dartsclone
 
 import (
 	"bufio"
+	"bytes"
+	"io/ioutil"
 	"os"
 	"testing"
 )
@@ -97,3 +99,73 @@ func TestOpenMmaped(t *testing.T) {
 		}
 	}
 }
+
+func TestOpenMmaped_Close(t *testing.T) {
+	f, err := ioutil.TempFile("", "trie_mmap_close_test")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer os.Remove(f.Name())
+	b := NewBuilder(nil)
+	if err := b.Build([]string{"hello", "world"}, nil); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if _, err := b.WriteTo(f); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	f.Close()
+
+	trie, err := OpenMmaped(f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if err := trie.Close(); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+	if _, _, err := trie.ExactMatchSearch("hello"); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	if _, err := trie.CommonPrefixSearch("hello", 0); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	if err := PredictiveSearchCallback(trie, "", func(string, int) {}); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestOpenMmapedSection(t *testing.T) {
+	keys := []string{"hello", "world"}
+	var buf bytes.Buffer
+	buf.WriteString("header")
+	offset := buf.Len()
+	b := NewBuilder(nil)
+	if err := b.Build(keys, []uint32{1<<31 + 1, 2}); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if _, err := b.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	size := buf.Len() - offset
+	buf.WriteString("trailer")
+	f, err := ioutil.TempFile("", "trie_mmap_section_test")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer os.Remove(f.Name())
+	f.Write(buf.Bytes())
+	f.Close()
+
+	trie, err := OpenMmapedSection(f.Name(), int64(offset), int64(size))
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer trie.Close()
+	large := uint32(1<<31 + 1)
+	for i, expected := range []int{int(large), 2} {
+		if id, _, err := trie.ExactMatchSearch(keys[i]); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		} else if id != expected {
+			t.Errorf("expected id=%v, got %v", expected, id)
+		}
+	}
+}
@@ -16,7 +16,10 @@ package dartsclone
 
 import (
 	"bufio"
+	"bytes"
+	"io/ioutil"
 	"os"
+	"reflect"
 	"sort"
 	"strings"
 	"testing"
@@ -147,3 +150,109 @@ func TestOpen(t *testing.T) {
 		}
 	}
 }
+
+func TestOpenSection(t *testing.T) {
+	keys := []string{"hello", "world"}
+	var buf bytes.Buffer
+	buf.WriteString("header")
+	offset32 := buf.Len()
+	b := NewBuilder(nil)
+	if err := b.Build(keys, []uint32{1, 2}); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if _, err := b.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	size32 := buf.Len() - offset32
+	offset64 := buf.Len()
+	large := uint32(1<<31 + 1)
+	if err := b.Build(keys, []uint32{large, 2}); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if _, err := b.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	size64 := buf.Len() - offset64
+	buf.WriteString("trailer")
+	f, err := ioutil.TempFile("", "trie_section_test")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer os.Remove(f.Name())
+	f.Write(buf.Bytes())
+	f.Close()
+
+	for _, tt := range []struct {
+		name         string
+		offset, size int
+		ids          []int
+	}{
+		{name: "32-bit", offset: offset32, size: size32, ids: []int{1, 2}},
+		{name: "64-bit", offset: offset64, size: size64, ids: []int{int(large), 2}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			trie, err := OpenSection(f.Name(), int64(tt.offset), int64(tt.size), OpenValidate())
+			if err != nil {
+				t.Fatalf("unexpected error, %v", err)
+			}
+			for i, expected := range tt.ids {
+				if id, _, err := trie.ExactMatchSearch(keys[i]); err != nil {
+					t.Errorf("unexpected error, %v", err)
+				} else if id != expected {
+					t.Errorf("expected id=%v, got %v", expected, id)
+				}
+			}
+		})
+	}
+	if _, err := OpenSection(f.Name(), int64(offset32), int64(buf.Len())); err == nil {
+		t.Errorf("expected error of the section out of the file")
+	}
+}
+
+// prefixOnlyTrie is the TRIE implemented outside, which does not list the keywords.
+type prefixOnlyTrie struct {
+	Trie
+}
+
+func TestKeys(t *testing.T) {
+	keys := []string{"a", "ab", "abc", "b"}
+	trie, err := BuildTRIE(keys, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	t.Run("keys", func(t *testing.T) {
+		for _, tr := range []Trie{trie, heapTrie{Trie: trie}} {
+			var got []string
+			it := Keys(tr, "a")
+			for it.Next() {
+				got = append(got, it.Key())
+			}
+			if err := it.Err(); err != nil {
+				t.Errorf("unexpected error, %v", err)
+			}
+			if expected := keys[:3]; !reflect.DeepEqual(expected, got) {
+				t.Errorf("expected %v, got %v", expected, got)
+			}
+		}
+	})
+	t.Run("predictive search", func(t *testing.T) {
+		var got []string
+		if err := PredictiveSearchCallback(heapTrie{Trie: trie}, "", func(key string, id int) {
+			got = append(got, key)
+		}); err != nil {
+			t.Errorf("unexpected error, %v", err)
+		}
+		if !reflect.DeepEqual(keys, got) {
+			t.Errorf("expected %v, got %v", keys, got)
+		}
+	})
+	t.Run("not supported", func(t *testing.T) {
+		tr := prefixOnlyTrie{Trie: trie}
+		if it := Keys(tr, ""); it.Next() || it.Err() == nil {
+			t.Errorf("expected not supported error")
+		}
+		if err := PredictiveSearchCallback(tr, "", func(string, int) {}); err == nil {
+			t.Errorf("expected not supported error")
+		}
+	})
+}
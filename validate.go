@@ -0,0 +1,47 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"fmt"
+
+	"github.com/ikawaha/dartsclone/internal"
+)
+
+// ValidationError is the error of the broken double array, which has the problems of the units.
+type ValidationError = internal.ValidationError
+
+// UnitProblem represents a problem of the unit.
+type UnitProblem = internal.UnitProblem
+
+// validator is the TRIE which validates the structure.
+type validator interface {
+	Validate() error
+}
+
+// Validate walks the double array of the TRIE from the root and checks the structure,
+// i.e. every leaf unit has the value flag, no offset escapes the array, no unit is reached again on the path from the root, which means a cycle,
+// and every node has a leaf or children. The units shared by the keys, e.g. the merged suffixes of the DAWG, are not problems.
+// It returns the ValidationError which has all problems if the structure is broken.
+func Validate(t Trie) error {
+	if h, ok := t.(heapTrie); ok {
+		t = h.Trie
+	}
+	v, ok := t.(validator)
+	if !ok {
+		return fmt.Errorf("validation is not supported by %T", t)
+	}
+	return v.Validate()
+}
@@ -0,0 +1,64 @@
+// Copyright 2018 ikawaha
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// 	You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dartsclone
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestOpenValidate(t *testing.T) {
+	f, err := ioutil.TempFile("", "validate_test")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer os.Remove(f.Name())
+	b := NewBuilder(nil)
+	if err := b.Build([]string{"hello", "world"}, nil); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if _, err := b.WriteTo(f); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	f.Close()
+	if _, err := Open(f.Name(), OpenValidate()); err != nil {
+		t.Errorf("unexpected error, %v", err)
+	}
+
+	data, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	// clears the first leaf unit, which has the value flag.
+	for i := 0; i < len(data); i += 4 {
+		if data[i+3]&0x80 != 0 {
+			copy(data[i:i+4], []byte{0, 0, 0, 0})
+			break
+		}
+	}
+	if err := ioutil.WriteFile(f.Name(), data, 0644); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if _, err := Open(f.Name()); err != nil {
+		t.Errorf("unexpected error without validation, %v", err)
+	}
+	_, err = Open(f.Name(), OpenValidate())
+	if v, ok := err.(*ValidationError); !ok {
+		t.Errorf("expected ValidationError, got %v", err)
+	} else if len(v.Problems) == 0 {
+		t.Errorf("expected problems")
+	}
+}